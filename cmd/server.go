@@ -2,25 +2,26 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/bradcypert/stserver/internal"
 	"github.com/bradcypert/stserver/internal/auth"
 	"github.com/bradcypert/stserver/internal/events"
+	"github.com/bradcypert/stserver/internal/fleet"
 	"github.com/bradcypert/stserver/internal/handlers"
+	"github.com/bradcypert/stserver/internal/island"
+	"github.com/bradcypert/stserver/internal/market"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
 )
 
-var ctx = context.Background()
-
 var rdb = redis.NewClient(&redis.Options{
 	Addr: "localhost:6379",
 })
@@ -44,7 +45,33 @@ func main() {
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
-	gameEngine := internal.NewGameEngine(logger, rdb, pool)
+
+	// Register game event handlers
+	registry := events.NewRegistry()
+	events.RegisterBuildHandlers(registry, pool)
+
+	queue := events.NewQueue(rdb, 30*time.Second, events.DefaultRetryPolicy)
+
+	// Island rules
+	islandConfig := island.DefaultConfig
+	if share := os.Getenv("CONSTRUCTION_REFUND_SHARE"); share != "" {
+		refundShare, err := strconv.ParseFloat(share, 64)
+		if err != nil || refundShare < 0 || refundShare > 1 {
+			fmt.Println("CONSTRUCTION_REFUND_SHARE must be between 0 and 1, got:", share)
+			os.Exit(1)
+		}
+		islandConfig.CancelRefundShare = refundShare
+	}
+	islandService := island.NewService(pool, queue, islandConfig)
+	island.RegisterEventHandlers(registry, islandService)
+
+	fleetService := fleet.NewService(pool, queue, islandService)
+	fleet.RegisterEventHandlers(registry, fleetService)
+
+	marketService := market.NewService(pool, queue, islandService)
+	market.RegisterEventHandlers(registry, marketService)
+
+	gameEngine := internal.NewGameEngine(logger, queue, pool, registry, islandService, marketService)
 
 	// Setup auth service
 	jwtSecret := os.Getenv("JWT_SECRET")
@@ -57,13 +84,13 @@ func main() {
 	go gameEngine.StartTickEngine(ctx)
 
 	// Simulate a scheduled build
-	err = scheduleBuildComplete(1, "trade_office", 10*time.Second)
+	err = scheduleBuildComplete(ctx, queue, 1, "trade_office", 10*time.Second)
 	if err != nil {
 		fmt.Println("Schedule error:", err)
 	}
 
 	// Auth endpoints
-	authHandler := handlers.NewAuthHandler(pool, authService)
+	authHandler := handlers.NewAuthHandler(pool, authService, islandService)
 	http.HandleFunc("POST /auth/signup", authHandler.Signup)
 	http.HandleFunc("POST /auth/login", authHandler.Login)
 	http.HandleFunc("POST /auth/verify-email", authHandler.VerifyEmail)
@@ -76,21 +103,67 @@ func main() {
 	factionHandler := handlers.NewFactionHandler(pool)
 	http.HandleFunc("GET /factions", factionHandler.GetAllFactions)
 	http.HandleFunc("GET /factions/{id}", factionHandler.GetFaction)
+	http.HandleFunc("GET /factions/{id}/treasury", factionHandler.GetFactionTreasury)
+	http.HandleFunc("GET /faction-relations", factionHandler.GetFactionRelations)
 	http.HandleFunc("POST /factions/join", authService.RequireAuth(factionHandler.JoinFaction))
 	http.HandleFunc("GET /player/faction", authService.RequireAuth(factionHandler.GetPlayerFaction))
 
 	// Island Management endpoints
-	islandHandler := handlers.NewIslandHandler(pool)
+	islandHandler := handlers.NewIslandHandler(pool, islandService)
 	http.HandleFunc("GET /my-island", authService.RequireAuth(islandHandler.GetPlayerIsland))
+	http.HandleFunc("GET /my-island/ledger", authService.RequireAuth(islandHandler.GetLedger))
 	http.HandleFunc("POST /my-island/buildings", authService.RequireAuth(islandHandler.ConstructBuilding))
 	http.HandleFunc("POST /buildings/{building_id}/upgrade", authService.RequireAuth(islandHandler.UpgradeBuilding))
-	http.HandleFunc("GET /building-types", islandHandler.GetBuildingTypes)
+	http.HandleFunc("POST /buildings/{building_id}/demolish", authService.RequireAuth(islandHandler.DemolishBuilding))
+	http.HandleFunc("DELETE /buildings/{building_id}/construction", authService.RequireAuth(islandHandler.CancelConstruction))
+	http.HandleFunc("PUT /my-island/construction-queue/{item_id}", authService.RequireAuth(islandHandler.MoveQueuedConstruction))
+	http.HandleFunc("DELETE /my-island/construction-queue/{item_id}", authService.RequireAuth(islandHandler.RemoveQueuedConstruction))
+	http.HandleFunc("GET /building-types", authService.OptionalAuth(islandHandler.GetBuildingTypes))
+	http.HandleFunc("GET /building-types/{type}/levels", islandHandler.GetBuildingLevels)
 	http.HandleFunc("GET /building-production", islandHandler.GetBuildingProduction)
+	http.HandleFunc("GET /resource-types", islandHandler.GetResourceTypes)
+
+	// Fleet endpoints
+	fleetHandler := handlers.NewFleetHandler(pool, fleetService)
+	http.HandleFunc("GET /ship-classes", fleetHandler.GetShipClasses)
+	http.HandleFunc("GET /my-island/ships", authService.RequireAuth(fleetHandler.GetPlayerShips))
+	http.HandleFunc("POST /my-island/ships", authService.RequireAuth(fleetHandler.BuildShip))
+	http.HandleFunc("GET /my-island/fleets", authService.RequireAuth(fleetHandler.GetPlayerFleets))
+	http.HandleFunc("POST /my-island/fleets", authService.RequireAuth(fleetHandler.CreateFleet))
+	http.HandleFunc("GET /fleets/{fleet_id}", authService.RequireAuth(fleetHandler.GetFleet))
+	http.HandleFunc("POST /fleets/{fleet_id}/dispatch", authService.RequireAuth(fleetHandler.DispatchFleet))
+	http.HandleFunc("DELETE /fleets/{fleet_id}", authService.RequireAuth(fleetHandler.DisbandFleet))
+	http.HandleFunc("POST /fleets/{fleet_id}/cargo/load", authService.RequireAuth(fleetHandler.LoadCargo))
+	http.HandleFunc("POST /fleets/{fleet_id}/cargo/unload", authService.RequireAuth(fleetHandler.UnloadCargo))
+	http.HandleFunc("GET /world/fleets", fleetHandler.GetFleetsNear)
+	http.HandleFunc("GET /my-island/battles", authService.RequireAuth(fleetHandler.GetPlayerBattles))
+	http.HandleFunc("GET /battles/{battle_id}", authService.RequireAuth(fleetHandler.GetBattle))
+	http.HandleFunc("GET /my-island/raids", authService.RequireAuth(fleetHandler.GetPlayerRaids))
+	http.HandleFunc("GET /raids/{raid_id}", authService.RequireAuth(fleetHandler.GetRaid))
+
+	// Market endpoints
+	marketHandler := handlers.NewMarketHandler(pool, marketService)
+	http.HandleFunc("GET /market/prices", marketHandler.GetNpcPrices)
+	http.HandleFunc("GET /market/posts", authService.OptionalAuth(marketHandler.GetTradingPosts))
+	http.HandleFunc("GET /market/{resource_type}/book", marketHandler.GetOrderBook)
+	http.HandleFunc("GET /my-island/market/orders", authService.RequireAuth(marketHandler.GetPlayerOrders))
+	http.HandleFunc("POST /my-island/market/orders", authService.RequireAuth(marketHandler.PlaceOrder))
+	http.HandleFunc("DELETE /my-island/market/orders/{order_id}", authService.RequireAuth(marketHandler.CancelOrder))
+	http.HandleFunc("POST /my-island/market/npc-trades", authService.RequireAuth(marketHandler.TradeWithNpcs))
+
+	// Admin endpoints
+	adminHandler := handlers.NewAdminHandler(pool, queue, islandService)
+	http.HandleFunc("GET /admin/events/dead", authService.RequireAuth(adminHandler.RequireAdmin(adminHandler.ListDeadEvents)))
+	http.HandleFunc("GET /admin/events/dead/{id}", authService.RequireAuth(adminHandler.RequireAdmin(adminHandler.GetDeadEvent)))
+	http.HandleFunc("POST /admin/events/dead/{id}/requeue", authService.RequireAuth(adminHandler.RequireAdmin(adminHandler.RequeueDeadEvent)))
+	http.HandleFunc("DELETE /admin/events/dead/{id}", authService.RequireAuth(adminHandler.RequireAdmin(adminHandler.DiscardDeadEvent)))
+	http.HandleFunc("POST /admin/ports/{port_id}/resources", authService.RequireAuth(adminHandler.RequireAdmin(adminHandler.GrantResources)))
+	http.HandleFunc("POST /admin/players/{player_id}/research", authService.RequireAuth(adminHandler.RequireAdmin(adminHandler.GrantResearch)))
 
 	go func() {
 		logger.Info("Server started on :4200")
 		if err := http.ListenAndServe(":4200", nil); err != http.ErrServerClosed {
-			logger.Error("ListenAndServe(): %s\n", err)
+			logger.Error("ListenAndServe()", slog.String("error", err.Error()))
 			panic(err)
 		}
 	}()
@@ -106,22 +179,14 @@ func main() {
 	fmt.Println("Goodbye.")
 }
 
-func scheduleBuildComplete(portID int32, buildingType string, delay time.Duration) error {
-	event := events.GameEvent{
-		EventType:    events.GameEventPortBuilding,
+func scheduleBuildComplete(ctx context.Context, queue *events.Queue, portID int32, buildingType string, delay time.Duration) error {
+	event, err := events.NewEvent(events.GameEventPortBuilding, events.PortBuildingPayload{
 		PortID:       portID,
 		BuildingType: buildingType,
-	}
-
-	data, err := json.Marshal(event)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to marshal build event: %w", err)
+		return fmt.Errorf("failed to create build event: %w", err)
 	}
 
-	score := float64(time.Now().Add(delay).Unix())
-
-	return rdb.ZAdd(ctx, "game_events", redis.Z{
-		Score:  score,
-		Member: data,
-	}).Err()
+	return queue.Schedule(ctx, event, time.Now().Add(delay))
 }
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Production rates were applied once per five second tick. Store them per
+-- hour instead so output no longer depends on how often the server ticks.
+ALTER TABLE building_production RENAME COLUMN production_rate TO production_per_hour;
+UPDATE building_production SET production_per_hour = production_per_hour * 720;
+
+-- Fractional output that has not yet added up to a whole unit
+CREATE TABLE building_production_carry (
+    building_id INTEGER NOT NULL REFERENCES buildings(id) ON DELETE CASCADE,
+    resource_type TEXT NOT NULL,
+    carry DOUBLE PRECISION NOT NULL DEFAULT 0,
+    PRIMARY KEY (building_id, resource_type)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE building_production_carry;
+UPDATE building_production SET production_per_hour = production_per_hour / 720;
+ALTER TABLE building_production RENAME COLUMN production_per_hour TO production_rate;
+-- +goose StatementEnd
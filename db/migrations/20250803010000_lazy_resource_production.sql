@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Resources are now stored as an amount at settled_at plus a production
+-- rate. The current amount is computed on read and only written back when a
+-- port spends resources or its production rates change.
+ALTER TABLE resources ADD COLUMN settled_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+CREATE TABLE port_production (
+    port_id INTEGER NOT NULL REFERENCES ports(id) ON DELETE CASCADE,
+    resource_type TEXT NOT NULL, -- matches column names in resources table
+    rate_per_hour INTEGER NOT NULL DEFAULT 0,
+    carry DOUBLE PRECISION NOT NULL DEFAULT 0, -- fractional output not yet settled
+    PRIMARY KEY (port_id, resource_type)
+);
+
+-- Seed rates from the buildings that are already operating
+INSERT INTO port_production (port_id, resource_type, rate_per_hour)
+SELECT b.port_id, bp.resource_type, SUM(bp.production_per_hour)
+FROM buildings b
+JOIN building_types bt ON b.type = bt.type_name
+JOIN building_production bp ON bp.building_type_id = bt.id AND bp.level = b.level
+WHERE b.under_construction = FALSE
+GROUP BY b.port_id, bp.resource_type;
+
+-- Buildings no longer produce individually
+DROP TABLE building_production_carry;
+ALTER TABLE buildings DROP COLUMN last_production_at;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE buildings ADD COLUMN last_production_at TIMESTAMPTZ DEFAULT NOW();
+CREATE TABLE building_production_carry (
+    building_id INTEGER NOT NULL REFERENCES buildings(id) ON DELETE CASCADE,
+    resource_type TEXT NOT NULL,
+    carry DOUBLE PRECISION NOT NULL DEFAULT 0,
+    PRIMARY KEY (building_id, resource_type)
+);
+DROP TABLE port_production;
+ALTER TABLE resources DROP COLUMN settled_at;
+-- +goose StatementEnd
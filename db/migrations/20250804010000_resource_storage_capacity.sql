@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Storage capacity per resource. A port can hold base_capacity of each
+-- resource plus capacity_per_warehouse_level for every completed warehouse
+-- level on the island.
+CREATE TABLE resource_storage (
+    resource_type TEXT PRIMARY KEY, -- matches column names in resources table
+    base_capacity INTEGER NOT NULL,
+    capacity_per_warehouse_level INTEGER NOT NULL
+);
+
+INSERT INTO resource_storage (resource_type, base_capacity, capacity_per_warehouse_level) VALUES
+('wood', 10000, 5000),
+('iron', 10000, 5000),
+('rum', 5000, 2500),
+('sugar', 5000, 2500),
+('tobacco', 5000, 2500),
+('cotton', 5000, 2500),
+('coffee', 5000, 2500),
+('grain', 10000, 5000),
+('gold', 5000, 2500),
+('silver', 2000, 1000);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE resource_storage;
+-- +goose StatementEnd
@@ -0,0 +1,54 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Every island gets one construction slot, plus one per carpenter level
+INSERT INTO building_types (type_name, display_name, description, category, max_level, base_cost_wood, base_cost_iron, base_cost_gold, base_build_time) VALUES
+('carpenter', 'Carpenter''s Workshop', 'Adds a construction slot per level so more buildings can be built at once', 'infrastructure', 3, 120, 40, 60, 600);
+
+-- Per-port build queue. Active items hold a construction slot and have a
+-- building row under construction; queued items wait for a slot in position
+-- order. Items are removed once their construction completes.
+CREATE TABLE construction_queue (
+    id SERIAL PRIMARY KEY,
+    port_id INTEGER NOT NULL REFERENCES ports(id) ON DELETE CASCADE,
+    building_id INTEGER REFERENCES buildings(id) ON DELETE CASCADE, -- set for upgrades, and for new buildings once started
+    building_type TEXT NOT NULL,
+    target_level INTEGER NOT NULL,
+    duration_seconds INTEGER NOT NULL,
+    cost_wood INTEGER NOT NULL DEFAULT 0,
+    cost_iron INTEGER NOT NULL DEFAULT 0,
+    cost_gold INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'queued', -- 'queued', 'active'
+    position INTEGER NOT NULL,
+    started_at TIMESTAMPTZ,
+    finishes_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_construction_queue_port_position ON construction_queue(port_id, position);
+CREATE INDEX idx_construction_queue_building_id ON construction_queue(building_id);
+CREATE INDEX idx_construction_queue_active_finishes_at ON construction_queue(finishes_at) WHERE status = 'active';
+
+-- Track constructions that were already running as active queue items
+INSERT INTO construction_queue (port_id, building_id, building_type, target_level, duration_seconds, status, position, started_at, finishes_at)
+SELECT
+    b.port_id,
+    b.id,
+    b.type,
+    b.level,
+    GREATEST(0, EXTRACT(EPOCH FROM b.construction_complete_at - NOW()))::integer,
+    'active',
+    ROW_NUMBER() OVER (PARTITION BY b.port_id ORDER BY b.construction_complete_at),
+    NOW(),
+    b.construction_complete_at
+FROM buildings b
+WHERE b.under_construction = TRUE;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE construction_queue;
+DELETE FROM buildings WHERE type = 'carpenter';
+DELETE FROM building_types WHERE type_name = 'carpenter';
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- What has been paid for each building's completed levels, so demolition
+-- can return a share of it
+ALTER TABLE buildings ADD COLUMN invested_wood INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE buildings ADD COLUMN invested_iron INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE buildings ADD COLUMN invested_gold INTEGER NOT NULL DEFAULT 0;
+
+-- Set while a building is being torn down; it no longer produces
+ALTER TABLE buildings ADD COLUMN demolish_at TIMESTAMPTZ;
+
+-- Level n has cost n times the base cost, so completed levels add up to
+-- base * n * (n + 1) / 2
+UPDATE buildings b
+SET invested_wood = bt.base_cost_wood * l.levels * (l.levels + 1) / 2,
+    invested_iron = bt.base_cost_iron * l.levels * (l.levels + 1) / 2,
+    invested_gold = bt.base_cost_gold * l.levels * (l.levels + 1) / 2
+FROM building_types bt,
+LATERAL (SELECT CASE WHEN b.under_construction THEN b.level - 1 ELSE b.level END AS levels) l
+WHERE bt.type_name = b.type;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE buildings DROP COLUMN demolish_at;
+ALTER TABLE buildings DROP COLUMN invested_gold;
+ALTER TABLE buildings DROP COLUMN invested_iron;
+ALTER TABLE buildings DROP COLUMN invested_wood;
+-- +goose StatementEnd
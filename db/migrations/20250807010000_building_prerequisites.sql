@@ -0,0 +1,60 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE research_types (
+    id SERIAL PRIMARY KEY,
+    name TEXT UNIQUE NOT NULL,
+    display_name TEXT NOT NULL,
+    description TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+INSERT INTO research_types (name, display_name, description) VALUES
+('navigation', 'Navigation', 'Charts and instruments for taking ships beyond sight of land'),
+('fortification', 'Fortification', 'Masonry and gun emplacements for defending the harbour'),
+('commerce', 'Commerce', 'Ledgers, contracts and letters of credit for trading abroad');
+
+-- Research a player has completed
+CREATE TABLE player_research (
+    player_id INTEGER NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    research_type TEXT NOT NULL REFERENCES research_types(name),
+    completed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (player_id, research_type)
+);
+
+-- What a building type needs before it can be built or upgraded to level.
+-- A requirement applies to its level and every level above it. A row can
+-- require another building at a minimum level, a completed research, or both.
+CREATE TABLE building_prerequisites (
+    id SERIAL PRIMARY KEY,
+    building_type TEXT NOT NULL REFERENCES building_types(type_name) ON DELETE CASCADE,
+    level INTEGER NOT NULL DEFAULT 1,
+    required_building TEXT REFERENCES building_types(type_name) ON DELETE CASCADE,
+    required_level INTEGER,
+    required_research TEXT REFERENCES research_types(name) ON DELETE CASCADE,
+    CHECK (required_building IS NOT NULL OR required_research IS NOT NULL),
+    CHECK ((required_building IS NULL) = (required_level IS NULL))
+);
+
+CREATE INDEX idx_building_prerequisites_building_type ON building_prerequisites(building_type, level);
+
+INSERT INTO building_prerequisites (building_type, level, required_building, required_level, required_research) VALUES
+('shipyard', 1, 'dock', 2, NULL),
+('shipyard', 1, 'lumberyard', 2, NULL),
+('shipyard', 1, NULL, NULL, 'navigation'),
+('fort', 1, 'mine', 2, NULL),
+('fort', 1, NULL, NULL, 'fortification'),
+('trade_center', 1, 'warehouse', 1, NULL),
+('trade_center', 1, NULL, NULL, 'commerce'),
+('carpenter', 1, 'lumberyard', 1, NULL),
+('carpenter', 2, 'warehouse', 2, NULL),
+('warehouse', 3, 'carpenter', 1, NULL);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE building_prerequisites;
+DROP TABLE player_research;
+DROP TABLE research_types;
+-- +goose StatementEnd
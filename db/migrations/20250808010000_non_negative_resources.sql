@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Spending no longer clamps at zero, so make an overdraft fail loudly
+ALTER TABLE resources
+    ADD CONSTRAINT resources_non_negative CHECK (
+        wood >= 0 AND iron >= 0 AND rum >= 0 AND sugar >= 0 AND tobacco >= 0
+        AND cotton >= 0 AND coffee >= 0 AND grain >= 0 AND gold >= 0 AND silver >= 0
+    );
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE resources DROP CONSTRAINT resources_non_negative;
+-- +goose StatementEnd
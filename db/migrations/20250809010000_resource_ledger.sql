@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Append-only history of every change to a port's resources. Rows are
+-- written in the same transaction as the change they record.
+CREATE TABLE resource_ledger (
+    id BIGSERIAL PRIMARY KEY,
+    port_id INTEGER NOT NULL REFERENCES ports(id) ON DELETE CASCADE,
+    resource_type TEXT NOT NULL,
+    delta INTEGER NOT NULL,
+    reason TEXT NOT NULL, -- 'production', 'construction', 'upgrade', 'refund', 'salvage', 'trade', 'admin_grant', 'starting_resources'
+    reference_id TEXT, -- what caused the change, e.g. a construction queue item or building id
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_resource_ledger_port_created_at ON resource_ledger(port_id, created_at DESC, id DESC);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE resource_ledger;
+-- +goose StatementEnd
@@ -0,0 +1,122 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Catalog of every resource in the game. Storage limits move here from
+-- resource_storage.
+CREATE TABLE resource_types (
+    name TEXT PRIMARY KEY,
+    display_name TEXT NOT NULL,
+    description TEXT,
+    base_capacity INTEGER NOT NULL,
+    capacity_per_warehouse_level INTEGER NOT NULL,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+INSERT INTO resource_types (name, display_name, base_capacity, capacity_per_warehouse_level, sort_order)
+SELECT
+    rs.resource_type,
+    INITCAP(rs.resource_type),
+    rs.base_capacity,
+    rs.capacity_per_warehouse_level,
+    array_position(ARRAY['wood', 'iron', 'gold', 'silver', 'grain', 'rum', 'sugar', 'tobacco', 'cotton', 'coffee'], rs.resource_type)
+FROM resource_storage rs;
+
+DROP TABLE resource_storage;
+
+-- One row per port and resource. A missing row means the port has none.
+CREATE TABLE port_resources (
+    port_id INTEGER NOT NULL REFERENCES ports(id) ON DELETE CASCADE,
+    resource_type TEXT NOT NULL REFERENCES resource_types(name),
+    amount INTEGER NOT NULL DEFAULT 0 CHECK (amount >= 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (port_id, resource_type)
+);
+
+INSERT INTO port_resources (port_id, resource_type, amount)
+SELECT r.port_id, v.resource_type, v.amount
+FROM resources r
+CROSS JOIN LATERAL (VALUES
+    ('wood', r.wood),
+    ('iron', r.iron),
+    ('rum', r.rum),
+    ('sugar', r.sugar),
+    ('tobacco', r.tobacco),
+    ('cotton', r.cotton),
+    ('coffee', r.coffee),
+    ('grain', r.grain),
+    ('gold', r.gold),
+    ('silver', r.silver)
+) AS v(resource_type, amount)
+WHERE v.amount <> 0;
+
+-- resources keeps one row per port with its settlement state. Locking it
+-- serializes changes to the port's resources.
+ALTER TABLE resources DROP CONSTRAINT resources_non_negative;
+ALTER TABLE resources
+    DROP COLUMN wood,
+    DROP COLUMN iron,
+    DROP COLUMN rum,
+    DROP COLUMN sugar,
+    DROP COLUMN tobacco,
+    DROP COLUMN cotton,
+    DROP COLUMN coffee,
+    DROP COLUMN grain,
+    DROP COLUMN gold,
+    DROP COLUMN silver;
+
+ALTER TABLE building_production
+    ADD CONSTRAINT building_production_resource_type_fkey FOREIGN KEY (resource_type) REFERENCES resource_types(name);
+ALTER TABLE port_production
+    ADD CONSTRAINT port_production_resource_type_fkey FOREIGN KEY (resource_type) REFERENCES resource_types(name);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE port_production DROP CONSTRAINT port_production_resource_type_fkey;
+ALTER TABLE building_production DROP CONSTRAINT building_production_resource_type_fkey;
+
+ALTER TABLE resources
+    ADD COLUMN wood INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN iron INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN rum INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN sugar INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN tobacco INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN cotton INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN coffee INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN grain INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN gold INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN silver INTEGER NOT NULL DEFAULT 0;
+
+UPDATE resources r
+SET wood = COALESCE((SELECT amount FROM port_resources pr WHERE pr.port_id = r.port_id AND pr.resource_type = 'wood'), 0),
+    iron = COALESCE((SELECT amount FROM port_resources pr WHERE pr.port_id = r.port_id AND pr.resource_type = 'iron'), 0),
+    rum = COALESCE((SELECT amount FROM port_resources pr WHERE pr.port_id = r.port_id AND pr.resource_type = 'rum'), 0),
+    sugar = COALESCE((SELECT amount FROM port_resources pr WHERE pr.port_id = r.port_id AND pr.resource_type = 'sugar'), 0),
+    tobacco = COALESCE((SELECT amount FROM port_resources pr WHERE pr.port_id = r.port_id AND pr.resource_type = 'tobacco'), 0),
+    cotton = COALESCE((SELECT amount FROM port_resources pr WHERE pr.port_id = r.port_id AND pr.resource_type = 'cotton'), 0),
+    coffee = COALESCE((SELECT amount FROM port_resources pr WHERE pr.port_id = r.port_id AND pr.resource_type = 'coffee'), 0),
+    grain = COALESCE((SELECT amount FROM port_resources pr WHERE pr.port_id = r.port_id AND pr.resource_type = 'grain'), 0),
+    gold = COALESCE((SELECT amount FROM port_resources pr WHERE pr.port_id = r.port_id AND pr.resource_type = 'gold'), 0),
+    silver = COALESCE((SELECT amount FROM port_resources pr WHERE pr.port_id = r.port_id AND pr.resource_type = 'silver'), 0);
+
+ALTER TABLE resources
+    ADD CONSTRAINT resources_non_negative CHECK (
+        wood >= 0 AND iron >= 0 AND rum >= 0 AND sugar >= 0 AND tobacco >= 0
+        AND cotton >= 0 AND coffee >= 0 AND grain >= 0 AND gold >= 0 AND silver >= 0
+    );
+
+DROP TABLE port_resources;
+
+CREATE TABLE resource_storage (
+    resource_type TEXT PRIMARY KEY,
+    base_capacity INTEGER NOT NULL,
+    capacity_per_warehouse_level INTEGER NOT NULL
+);
+
+INSERT INTO resource_storage (resource_type, base_capacity, capacity_per_warehouse_level)
+SELECT name, base_capacity, capacity_per_warehouse_level FROM resource_types;
+
+DROP TABLE resource_types;
+-- +goose StatementEnd
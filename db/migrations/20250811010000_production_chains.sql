@@ -0,0 +1,66 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- A building_production row is now one flow of a building: an output it
+-- produces, an input it converts, or upkeep it consumes just to operate.
+-- Rates are all per hour.
+ALTER TABLE building_production
+    ADD COLUMN flow TEXT NOT NULL DEFAULT 'output' CHECK (flow IN ('output', 'input', 'upkeep'));
+
+ALTER TABLE building_production DROP CONSTRAINT building_production_building_type_id_level_resource_type_key;
+ALTER TABLE building_production ADD CONSTRAINT building_production_building_type_id_level_resource_type_flow_key
+    UNIQUE (building_type_id, level, resource_type, flow);
+
+-- The resource a building ran out of at the last settlement. NULL when it
+-- had everything it needed.
+ALTER TABLE buildings ADD COLUMN stalled_resource TEXT REFERENCES resource_types(name);
+
+INSERT INTO building_types (type_name, display_name, description, category, max_level, base_cost_wood, base_cost_iron, base_cost_gold, base_build_time) VALUES
+('distillery', 'Distillery', 'Distills sugar into rum', 'resource', 5, 60, 20, 30, 420);
+
+INSERT INTO building_prerequisites (building_type, level, required_building, required_level, required_research) VALUES
+('distillery', 1, 'plantation', 1, NULL);
+
+INSERT INTO building_production (building_type_id, level, resource_type, production_per_hour, flow) VALUES
+((SELECT id FROM building_types WHERE type_name = 'distillery'), 1, 'sugar', 1440, 'input'),
+((SELECT id FROM building_types WHERE type_name = 'distillery'), 1, 'rum', 720, 'output'),
+((SELECT id FROM building_types WHERE type_name = 'distillery'), 2, 'sugar', 2160, 'input'),
+((SELECT id FROM building_types WHERE type_name = 'distillery'), 2, 'rum', 1080, 'output'),
+((SELECT id FROM building_types WHERE type_name = 'distillery'), 3, 'sugar', 2880, 'input'),
+((SELECT id FROM building_types WHERE type_name = 'distillery'), 3, 'rum', 1440, 'output'),
+((SELECT id FROM building_types WHERE type_name = 'distillery'), 4, 'sugar', 4320, 'input'),
+((SELECT id FROM building_types WHERE type_name = 'distillery'), 4, 'rum', 2160, 'output'),
+((SELECT id FROM building_types WHERE type_name = 'distillery'), 5, 'sugar', 5760, 'input'),
+((SELECT id FROM building_types WHERE type_name = 'distillery'), 5, 'rum', 2880, 'output');
+
+-- Garrisons eat, and taverns go through rum and grain
+INSERT INTO building_production (building_type_id, level, resource_type, production_per_hour, flow)
+SELECT bt.id, lvl, 'grain', 360 * lvl, 'upkeep'
+FROM building_types bt, generate_series(1, bt.max_level) AS lvl
+WHERE bt.type_name = 'fort';
+
+INSERT INTO building_production (building_type_id, level, resource_type, production_per_hour, flow)
+SELECT bt.id, lvl, upkeep.resource_type, upkeep.per_level * lvl, 'upkeep'
+FROM building_types bt, generate_series(1, bt.max_level) AS lvl,
+    (VALUES ('rum', 180), ('grain', 360)) AS upkeep(resource_type, per_level)
+WHERE bt.type_name = 'tavern';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM building_production WHERE flow <> 'output';
+DELETE FROM building_production
+WHERE building_type_id = (SELECT id FROM building_types WHERE type_name = 'distillery');
+DELETE FROM building_prerequisites WHERE building_type = 'distillery' OR required_building = 'distillery';
+DELETE FROM construction_queue WHERE building_type = 'distillery';
+DELETE FROM buildings WHERE type = 'distillery';
+DELETE FROM building_types WHERE type_name = 'distillery';
+
+ALTER TABLE buildings DROP COLUMN stalled_resource;
+
+ALTER TABLE building_production DROP CONSTRAINT building_production_building_type_id_level_resource_type_flow_key;
+ALTER TABLE building_production ADD CONSTRAINT building_production_building_type_id_level_resource_type_key
+    UNIQUE (building_type_id, level, resource_type);
+ALTER TABLE building_production DROP COLUMN flow;
+-- +goose StatementEnd
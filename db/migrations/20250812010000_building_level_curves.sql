@@ -0,0 +1,134 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Cost to reach a level, per resource, as a curve:
+--   base_amount * level ^ exponent * multiplier ^ (level - 1)
+-- With both parameters at 1 this is base_amount * level, the cost used so far.
+CREATE TABLE building_cost_curves (
+    building_type TEXT NOT NULL REFERENCES building_types(type_name) ON DELETE CASCADE,
+    resource_type TEXT NOT NULL REFERENCES resource_types(name),
+    base_amount INTEGER NOT NULL CHECK (base_amount >= 0),
+    multiplier DOUBLE PRECISION NOT NULL DEFAULT 1 CHECK (multiplier > 0),
+    exponent DOUBLE PRECISION NOT NULL DEFAULT 1,
+    PRIMARY KEY (building_type, resource_type)
+);
+
+INSERT INTO building_cost_curves (building_type, resource_type, base_amount)
+SELECT type_name, 'wood', base_cost_wood FROM building_types WHERE base_cost_wood > 0
+UNION ALL
+SELECT type_name, 'iron', base_cost_iron FROM building_types WHERE base_cost_iron > 0
+UNION ALL
+SELECT type_name, 'gold', base_cost_gold FROM building_types WHERE base_cost_gold > 0;
+
+-- Build time follows the same curve from base_build_time
+ALTER TABLE building_types
+    ADD COLUMN build_time_multiplier DOUBLE PRECISION NOT NULL DEFAULT 1 CHECK (build_time_multiplier > 0),
+    ADD COLUMN build_time_exponent DOUBLE PRECISION NOT NULL DEFAULT 1;
+
+-- Explicit tables for levels that shouldn't follow the curve. Any cost rows
+-- for a level replace that level's curve costs entirely.
+CREATE TABLE building_level_costs (
+    building_type TEXT NOT NULL REFERENCES building_types(type_name) ON DELETE CASCADE,
+    level INTEGER NOT NULL CHECK (level >= 1),
+    resource_type TEXT NOT NULL REFERENCES resource_types(name),
+    amount INTEGER NOT NULL CHECK (amount >= 0),
+    PRIMARY KEY (building_type, level, resource_type)
+);
+
+CREATE TABLE building_level_times (
+    building_type TEXT NOT NULL REFERENCES building_types(type_name) ON DELETE CASCADE,
+    level INTEGER NOT NULL CHECK (level >= 1),
+    duration_seconds INTEGER NOT NULL CHECK (duration_seconds >= 0),
+    PRIMARY KEY (building_type, level)
+);
+
+-- Costs paid for queued constructions and invested in buildings can be any
+-- resource now, so they move out of fixed columns.
+CREATE TABLE construction_queue_costs (
+    queue_item_id INTEGER NOT NULL REFERENCES construction_queue(id) ON DELETE CASCADE,
+    resource_type TEXT NOT NULL REFERENCES resource_types(name),
+    amount INTEGER NOT NULL CHECK (amount >= 0),
+    PRIMARY KEY (queue_item_id, resource_type)
+);
+
+INSERT INTO construction_queue_costs (queue_item_id, resource_type, amount)
+SELECT id, 'wood', cost_wood FROM construction_queue WHERE cost_wood > 0
+UNION ALL
+SELECT id, 'iron', cost_iron FROM construction_queue WHERE cost_iron > 0
+UNION ALL
+SELECT id, 'gold', cost_gold FROM construction_queue WHERE cost_gold > 0;
+
+CREATE TABLE building_investments (
+    building_id INTEGER NOT NULL REFERENCES buildings(id) ON DELETE CASCADE,
+    resource_type TEXT NOT NULL REFERENCES resource_types(name),
+    amount INTEGER NOT NULL CHECK (amount >= 0),
+    PRIMARY KEY (building_id, resource_type)
+);
+
+INSERT INTO building_investments (building_id, resource_type, amount)
+SELECT id, 'wood', invested_wood FROM buildings WHERE invested_wood > 0
+UNION ALL
+SELECT id, 'iron', invested_iron FROM buildings WHERE invested_iron > 0
+UNION ALL
+SELECT id, 'gold', invested_gold FROM buildings WHERE invested_gold > 0;
+
+ALTER TABLE construction_queue
+    DROP COLUMN cost_wood,
+    DROP COLUMN cost_iron,
+    DROP COLUMN cost_gold;
+
+ALTER TABLE buildings
+    DROP COLUMN invested_wood,
+    DROP COLUMN invested_iron,
+    DROP COLUMN invested_gold;
+
+ALTER TABLE building_types
+    DROP COLUMN base_cost_wood,
+    DROP COLUMN base_cost_iron,
+    DROP COLUMN base_cost_gold;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE building_types
+    ADD COLUMN base_cost_wood INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN base_cost_iron INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN base_cost_gold INTEGER NOT NULL DEFAULT 0;
+
+UPDATE building_types bt
+SET base_cost_wood = COALESCE((SELECT base_amount FROM building_cost_curves c WHERE c.building_type = bt.type_name AND c.resource_type = 'wood'), 0),
+    base_cost_iron = COALESCE((SELECT base_amount FROM building_cost_curves c WHERE c.building_type = bt.type_name AND c.resource_type = 'iron'), 0),
+    base_cost_gold = COALESCE((SELECT base_amount FROM building_cost_curves c WHERE c.building_type = bt.type_name AND c.resource_type = 'gold'), 0);
+
+ALTER TABLE buildings
+    ADD COLUMN invested_wood INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN invested_iron INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN invested_gold INTEGER NOT NULL DEFAULT 0;
+
+UPDATE buildings b
+SET invested_wood = COALESCE((SELECT amount FROM building_investments i WHERE i.building_id = b.id AND i.resource_type = 'wood'), 0),
+    invested_iron = COALESCE((SELECT amount FROM building_investments i WHERE i.building_id = b.id AND i.resource_type = 'iron'), 0),
+    invested_gold = COALESCE((SELECT amount FROM building_investments i WHERE i.building_id = b.id AND i.resource_type = 'gold'), 0);
+
+ALTER TABLE construction_queue
+    ADD COLUMN cost_wood INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN cost_iron INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN cost_gold INTEGER NOT NULL DEFAULT 0;
+
+UPDATE construction_queue q
+SET cost_wood = COALESCE((SELECT amount FROM construction_queue_costs c WHERE c.queue_item_id = q.id AND c.resource_type = 'wood'), 0),
+    cost_iron = COALESCE((SELECT amount FROM construction_queue_costs c WHERE c.queue_item_id = q.id AND c.resource_type = 'iron'), 0),
+    cost_gold = COALESCE((SELECT amount FROM construction_queue_costs c WHERE c.queue_item_id = q.id AND c.resource_type = 'gold'), 0);
+
+DROP TABLE building_investments;
+DROP TABLE construction_queue_costs;
+DROP TABLE building_level_times;
+DROP TABLE building_level_costs;
+
+ALTER TABLE building_types
+    DROP COLUMN build_time_multiplier,
+    DROP COLUMN build_time_exponent;
+
+DROP TABLE building_cost_curves;
+-- +goose StatementEnd
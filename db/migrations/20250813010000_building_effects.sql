@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Modifiers a building gives its island at each level. Values from every
+-- operating building on an island add up:
+--   production_multiplier  extra share of resource output (0.02 = +2%)
+--   build_time_reduction   share taken off construction times
+--   defense_rating         points of island defense
+--   crew_capacity          sailors the island can house
+--   trade_slots            open market orders the island can hold
+--   build_slots            extra constructions that can run at once
+CREATE TABLE building_effects (
+    id SERIAL PRIMARY KEY,
+    building_type TEXT NOT NULL REFERENCES building_types(type_name) ON DELETE CASCADE,
+    level INTEGER NOT NULL CHECK (level >= 1),
+    effect TEXT NOT NULL CHECK (effect IN (
+        'production_multiplier',
+        'build_time_reduction',
+        'defense_rating',
+        'crew_capacity',
+        'trade_slots',
+        'build_slots'
+    )),
+    value DOUBLE PRECISION NOT NULL,
+    UNIQUE (building_type, level, effect)
+);
+
+CREATE INDEX idx_building_effects_type_level ON building_effects(building_type, level);
+
+INSERT INTO building_effects (building_type, level, effect, value)
+SELECT bt.type_name, lvl, e.effect, e.per_level * lvl
+FROM building_types bt
+JOIN (VALUES
+    ('carpenter', 'build_slots', 1),
+    ('carpenter', 'build_time_reduction', 0.05),
+    ('tavern', 'crew_capacity', 25),
+    ('tavern', 'production_multiplier', 0.02),
+    ('fort', 'defense_rating', 50),
+    ('dock', 'trade_slots', 1),
+    ('dock', 'crew_capacity', 10),
+    ('trade_center', 'trade_slots', 2),
+    ('shipyard', 'crew_capacity', 15),
+    ('shipyard', 'defense_rating', 10)
+) AS e(building_type, effect, per_level) ON e.building_type = bt.type_name
+CROSS JOIN generate_series(1, bt.max_level) AS lvl;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE building_effects;
+-- +goose StatementEnd
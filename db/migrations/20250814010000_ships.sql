@@ -0,0 +1,73 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Ship designs a shipyard can build. Speed is in grid units per hour.
+CREATE TABLE ship_classes (
+    name TEXT PRIMARY KEY,
+    display_name TEXT NOT NULL,
+    description TEXT,
+    required_shipyard_level INTEGER NOT NULL CHECK (required_shipyard_level >= 1),
+    build_time_seconds INTEGER NOT NULL CHECK (build_time_seconds >= 0),
+    cargo_capacity INTEGER NOT NULL CHECK (cargo_capacity >= 0),
+    speed DOUBLE PRECISION NOT NULL CHECK (speed > 0),
+    hull INTEGER NOT NULL CHECK (hull > 0),
+    cannons INTEGER NOT NULL CHECK (cannons >= 0),
+    crew INTEGER NOT NULL CHECK (crew >= 0),
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE ship_class_costs (
+    ship_class TEXT NOT NULL REFERENCES ship_classes(name) ON DELETE CASCADE,
+    resource_type TEXT NOT NULL REFERENCES resource_types(name),
+    amount INTEGER NOT NULL CHECK (amount >= 0),
+    PRIMARY KEY (ship_class, resource_type)
+);
+
+INSERT INTO ship_classes (name, display_name, description, required_shipyard_level, build_time_seconds, cargo_capacity, speed, hull, cannons, crew, sort_order) VALUES
+('sloop', 'Sloop', 'A small, quick single-masted ship for scouting and light trade', 1, 600, 500, 12, 100, 6, 20, 1),
+('brig', 'Brig', 'A two-masted workhorse that balances cargo and guns', 2, 1200, 1200, 10, 250, 14, 60, 2),
+('frigate', 'Frigate', 'A fast warship built to hunt and escort', 3, 2400, 2000, 9, 500, 32, 150, 3),
+('galleon', 'Galleon', 'A slow, heavily armed giant with an enormous hold', 5, 3600, 5000, 6, 800, 40, 250, 4);
+
+INSERT INTO ship_class_costs (ship_class, resource_type, amount) VALUES
+('sloop', 'wood', 200),
+('sloop', 'iron', 50),
+('sloop', 'gold', 50),
+('brig', 'wood', 400),
+('brig', 'iron', 150),
+('brig', 'gold', 120),
+('brig', 'cotton', 100),
+('frigate', 'wood', 800),
+('frigate', 'iron', 400),
+('frigate', 'gold', 300),
+('frigate', 'cotton', 200),
+('galleon', 'wood', 1500),
+('galleon', 'iron', 700),
+('galleon', 'gold', 600),
+('galleon', 'cotton', 400);
+
+-- A player's ships. A ship under construction or docked sits at port_id.
+CREATE TABLE ships (
+    id SERIAL PRIMARY KEY,
+    player_id INTEGER NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    port_id INTEGER REFERENCES ports(id) ON DELETE SET NULL,
+    ship_class TEXT NOT NULL REFERENCES ship_classes(name),
+    name TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('under_construction', 'docked')),
+    hull INTEGER NOT NULL,
+    completes_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_ships_player_id ON ships(player_id);
+CREATE INDEX idx_ships_port_id ON ships(port_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE ships;
+DROP TABLE ship_class_costs;
+DROP TABLE ship_classes;
+-- +goose StatementEnd
@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- A group of ships that moves together. A docked fleet is in port_id, an
+-- anchored one waits at (x, y) in open water, and a sailing one is moving
+-- in a straight line from (origin_x, origin_y) at departed_at to
+-- (target_x, target_y) at arrives_at. Positions in between are interpolated.
+CREATE TABLE fleets (
+    id SERIAL PRIMARY KEY,
+    player_id INTEGER NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('docked', 'anchored', 'sailing')),
+    port_id INTEGER REFERENCES ports(id) ON DELETE SET NULL,
+    x DOUBLE PRECISION NOT NULL,
+    y DOUBLE PRECISION NOT NULL,
+    origin_x DOUBLE PRECISION,
+    origin_y DOUBLE PRECISION,
+    target_x DOUBLE PRECISION,
+    target_y DOUBLE PRECISION,
+    target_port_id INTEGER REFERENCES ports(id) ON DELETE SET NULL,
+    departed_at TIMESTAMPTZ,
+    arrives_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (status <> 'sailing' OR (departed_at IS NOT NULL AND arrives_at IS NOT NULL))
+);
+
+CREATE INDEX idx_fleets_player_id ON fleets(player_id);
+CREATE INDEX idx_fleets_status ON fleets(status);
+
+-- Ships in a fleet share its position. They are docked while the fleet is
+-- in port and at sea otherwise.
+ALTER TABLE ships ADD COLUMN fleet_id INTEGER REFERENCES fleets(id) ON DELETE SET NULL;
+ALTER TABLE ships DROP CONSTRAINT ships_status_check;
+ALTER TABLE ships ADD CONSTRAINT ships_status_check CHECK (status IN ('under_construction', 'docked', 'at_sea'));
+
+CREATE INDEX idx_ships_fleet_id ON ships(fleet_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE ships SET status = 'docked' WHERE status = 'at_sea';
+ALTER TABLE ships DROP CONSTRAINT ships_status_check;
+ALTER TABLE ships ADD CONSTRAINT ships_status_check CHECK (status IN ('under_construction', 'docked'));
+ALTER TABLE ships DROP COLUMN fleet_id;
+DROP TABLE fleets;
+-- +goose StatementEnd
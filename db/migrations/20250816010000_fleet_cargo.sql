@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- What a fleet is carrying. The total across resources never exceeds the
+-- combined cargo capacity of the fleet's ships. Cargo goes down with the
+-- fleet unless it is captured first.
+CREATE TABLE fleet_cargo (
+    fleet_id INTEGER NOT NULL REFERENCES fleets(id) ON DELETE CASCADE,
+    resource_type TEXT NOT NULL REFERENCES resource_types(name),
+    amount INTEGER NOT NULL CHECK (amount >= 0),
+    PRIMARY KEY (fleet_id, resource_type)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE fleet_cargo;
+-- +goose StatementEnd
@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Limit orders on the player market. Prices are in gold per unit. What an
+-- order offers is held in escrow while it is open: the resource for a sell
+-- order, and price_per_unit * remaining gold for a buy order.
+CREATE TABLE market_orders (
+    id SERIAL PRIMARY KEY,
+    player_id INTEGER NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    port_id INTEGER NOT NULL REFERENCES ports(id) ON DELETE CASCADE,
+    side TEXT NOT NULL CHECK (side IN ('buy', 'sell')),
+    resource_type TEXT NOT NULL REFERENCES resource_types(name) CHECK (resource_type <> 'gold'),
+    price_per_unit INTEGER NOT NULL CHECK (price_per_unit > 0),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    remaining INTEGER NOT NULL CHECK (remaining >= 0 AND remaining <= quantity),
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'filled', 'cancelled', 'expired')),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    closed_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_market_orders_book ON market_orders(resource_type, side, price_per_unit) WHERE status = 'open';
+CREATE INDEX idx_market_orders_player_id ON market_orders(player_id);
+
+-- Fills between two orders. A trade always happens at the price of the
+-- order that was already on the book.
+CREATE TABLE market_trades (
+    id SERIAL PRIMARY KEY,
+    resource_type TEXT NOT NULL REFERENCES resource_types(name),
+    buy_order_id INTEGER NOT NULL REFERENCES market_orders(id) ON DELETE CASCADE,
+    sell_order_id INTEGER NOT NULL REFERENCES market_orders(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    price_per_unit INTEGER NOT NULL CHECK (price_per_unit > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_market_trades_resource_type ON market_trades(resource_type, created_at DESC);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE market_trades;
+DROP TABLE market_orders;
+-- +goose StatementEnd
@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- The NPC trading posts' price for each resource, in gold per unit. Players
+-- buy a little above it and sell a little below it. pending_volume is the
+-- net amount players have bought (negative when they sold more) since the
+-- price was last recalculated; each recalculation moves the price by that
+-- volume relative to depth and lets it recover towards baseline_price.
+CREATE TABLE npc_market_prices (
+    resource_type TEXT PRIMARY KEY REFERENCES resource_types(name) CHECK (resource_type <> 'gold'),
+    baseline_price DOUBLE PRECISION NOT NULL CHECK (baseline_price > 0),
+    price DOUBLE PRECISION NOT NULL CHECK (price > 0),
+    depth INTEGER NOT NULL CHECK (depth > 0),
+    pending_volume INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO npc_market_prices (resource_type, baseline_price, price, depth) VALUES
+('wood', 5, 5, 20000),
+('iron', 10, 10, 10000),
+('silver', 20, 20, 5000),
+('grain', 4, 4, 20000),
+('rum', 15, 15, 5000),
+('sugar', 8, 8, 10000),
+('tobacco', 12, 12, 5000),
+('cotton', 8, 8, 10000),
+('coffee', 12, 12, 5000);
+
+-- One row per resource each time prices are recalculated, with the volume
+-- that went into it.
+CREATE TABLE npc_price_history (
+    id SERIAL PRIMARY KEY,
+    resource_type TEXT NOT NULL REFERENCES resource_types(name),
+    price DOUBLE PRECISION NOT NULL,
+    volume INTEGER NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_npc_price_history_recorded_at ON npc_price_history(recorded_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE npc_price_history;
+DROP TABLE npc_market_prices;
+-- +goose StatementEnd
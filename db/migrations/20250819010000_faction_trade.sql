@@ -0,0 +1,89 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- How a faction's trading posts deal. buy_modifier scales what players pay
+-- them and sell_modifier what they pay players. tariff_rate is the share of
+-- each trade's value they charge on top, which goes into the treasury.
+ALTER TABLE factions
+    ADD COLUMN buy_modifier DOUBLE PRECISION NOT NULL DEFAULT 1 CHECK (buy_modifier > 0),
+    ADD COLUMN sell_modifier DOUBLE PRECISION NOT NULL DEFAULT 1 CHECK (sell_modifier > 0),
+    ADD COLUMN tariff_rate DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (tariff_rate >= 0 AND tariff_rate < 1),
+    ADD COLUMN treasury BIGINT NOT NULL DEFAULT 0;
+
+UPDATE factions SET buy_modifier = 1.00, sell_modifier = 0.95, tariff_rate = 0.08 WHERE name = 'British';
+UPDATE factions SET buy_modifier = 1.05, sell_modifier = 1.00, tariff_rate = 0.05 WHERE name = 'French';
+UPDATE factions SET buy_modifier = 0.95, sell_modifier = 0.90, tariff_rate = 0.10 WHERE name = 'Spanish';
+UPDATE factions SET buy_modifier = 1.00, sell_modifier = 1.02, tariff_rate = 0.03 WHERE name = 'Dutch';
+
+-- How a trading post treats a player depending on how its faction stands
+-- with theirs. rate_bonus improves the price both ways (negative makes it
+-- worse) and tariff_multiplier scales the tariff. 'own' applies when the
+-- player belongs to the post's faction.
+CREATE TABLE faction_stances (
+    stance TEXT PRIMARY KEY CHECK (stance IN ('own', 'allied', 'neutral', 'hostile')),
+    rate_bonus DOUBLE PRECISION NOT NULL CHECK (rate_bonus > -1 AND rate_bonus < 1),
+    tariff_multiplier DOUBLE PRECISION NOT NULL CHECK (tariff_multiplier >= 0)
+);
+
+INSERT INTO faction_stances (stance, rate_bonus, tariff_multiplier) VALUES
+('own', 0.03, 0),
+('allied', 0.02, 0.5),
+('neutral', 0, 1),
+('hostile', -0.10, 2);
+
+-- Stances between factions, stored in both directions. Pairs without a row
+-- are neutral.
+CREATE TABLE faction_relations (
+    faction_id INTEGER NOT NULL REFERENCES factions(id) ON DELETE CASCADE,
+    other_faction_id INTEGER NOT NULL REFERENCES factions(id) ON DELETE CASCADE,
+    stance TEXT NOT NULL REFERENCES faction_stances(stance) CHECK (stance IN ('allied', 'hostile')),
+    PRIMARY KEY (faction_id, other_faction_id),
+    CHECK (faction_id <> other_faction_id)
+);
+
+INSERT INTO faction_relations (faction_id, other_faction_id, stance)
+SELECT a.id, b.id, r.stance
+FROM (VALUES
+    ('British', 'Dutch', 'allied'),
+    ('French', 'Spanish', 'allied'),
+    ('British', 'French', 'hostile'),
+    ('British', 'Spanish', 'hostile'),
+    ('Dutch', 'Spanish', 'hostile')
+) AS r(faction, other, stance)
+CROSS JOIN LATERAL (VALUES (r.faction, r.other), (r.other, r.faction)) AS pair(faction, other)
+JOIN factions a ON a.name = pair.faction
+JOIN factions b ON b.name = pair.other;
+
+-- NPC trading posts. They share the NPC market prices but each deals on
+-- its faction's terms.
+CREATE TABLE npc_trading_posts (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    faction_id INTEGER NOT NULL REFERENCES factions(id),
+    x INTEGER NOT NULL,
+    y INTEGER NOT NULL
+);
+
+INSERT INTO npc_trading_posts (name, faction_id, x, y)
+SELECT p.name, f.id, p.x, p.y
+FROM (VALUES
+    ('Port Royal', 'British', 250, 250),
+    ('Tortuga', 'French', 750, 250),
+    ('Havana', 'Spanish', 250, 750),
+    ('Willemstad', 'Dutch', 750, 750)
+) AS p(name, faction, x, y)
+JOIN factions f ON f.name = p.faction;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE npc_trading_posts;
+DROP TABLE faction_relations;
+DROP TABLE faction_stances;
+ALTER TABLE factions
+    DROP COLUMN treasury,
+    DROP COLUMN tariff_rate,
+    DROP COLUMN sell_modifier,
+    DROP COLUMN buy_modifier;
+-- +goose StatementEnd
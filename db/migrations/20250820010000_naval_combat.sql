@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- One row per engagement that was fought. The fleets may be gone by the
+-- time the report is read, so who took part is kept alongside them.
+-- engagement_id makes resolving the same scheduled engagement twice a
+-- no-op, and report holds the full round by round account as JSON.
+CREATE TABLE battle_reports (
+    id SERIAL PRIMARY KEY,
+    engagement_id TEXT NOT NULL UNIQUE,
+    attacker_player_id INTEGER NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    defender_player_id INTEGER NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    attacker_fleet_id INTEGER REFERENCES fleets(id) ON DELETE SET NULL,
+    defender_fleet_id INTEGER REFERENCES fleets(id) ON DELETE SET NULL,
+    port_id INTEGER REFERENCES ports(id) ON DELETE SET NULL,
+    x DOUBLE PRECISION NOT NULL,
+    y DOUBLE PRECISION NOT NULL,
+    outcome TEXT NOT NULL CHECK (outcome IN ('attacker', 'defender', 'draw')),
+    report JSONB NOT NULL,
+    fought_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_battle_reports_attacker ON battle_reports(attacker_player_id, fought_at DESC);
+CREATE INDEX idx_battle_reports_defender ON battle_reports(defender_player_id, fought_at DESC);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE battle_reports;
+-- +goose StatementEnd
@@ -0,0 +1,62 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Warehouses keep part of every resource out of raiders' reach:
+--   protected_storage      amount of each resource that can't be looted
+ALTER TABLE building_effects DROP CONSTRAINT building_effects_effect_check;
+ALTER TABLE building_effects ADD CONSTRAINT building_effects_effect_check CHECK (effect IN (
+    'production_multiplier',
+    'build_time_reduction',
+    'defense_rating',
+    'crew_capacity',
+    'trade_slots',
+    'build_slots',
+    'protected_storage'
+));
+
+INSERT INTO building_effects (building_type, level, effect, value)
+SELECT bt.type_name, lvl, 'protected_storage', 1000 * lvl
+FROM building_types bt
+CROSS JOIN generate_series(1, bt.max_level) AS lvl
+WHERE bt.type_name = 'warehouse';
+
+-- A raiding fleet is sailing to attack the port it's bound for rather than
+-- to dock there.
+ALTER TABLE fleets ADD COLUMN raiding BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- One row per raid on a port. Like battle_reports, raid_id makes resolving
+-- the same scheduled raid twice a no-op and report holds the fight and the
+-- loot as JSON.
+CREATE TABLE raid_reports (
+    id SERIAL PRIMARY KEY,
+    raid_id TEXT NOT NULL UNIQUE,
+    attacker_player_id INTEGER NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    defender_player_id INTEGER NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    fleet_id INTEGER REFERENCES fleets(id) ON DELETE SET NULL,
+    port_id INTEGER NOT NULL REFERENCES ports(id) ON DELETE CASCADE,
+    defense_rating INTEGER NOT NULL,
+    outcome TEXT NOT NULL CHECK (outcome IN ('attacker', 'defender', 'draw')),
+    report JSONB NOT NULL,
+    raided_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_raid_reports_attacker ON raid_reports(attacker_player_id, raided_at DESC);
+CREATE INDEX idx_raid_reports_defender ON raid_reports(defender_player_id, raided_at DESC);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE raid_reports;
+ALTER TABLE fleets DROP COLUMN raiding;
+DELETE FROM building_effects WHERE effect = 'protected_storage';
+ALTER TABLE building_effects DROP CONSTRAINT building_effects_effect_check;
+ALTER TABLE building_effects ADD CONSTRAINT building_effects_effect_check CHECK (effect IN (
+    'production_multiplier',
+    'build_time_reduction',
+    'defense_rating',
+    'crew_capacity',
+    'trade_slots',
+    'build_slots'
+));
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- New islands can't be raided until protected_until. Protection lasts three
+-- days from when the island is founded, and ends as soon as its owner
+-- raids someone else.
+ALTER TABLE ports ADD COLUMN protected_until TIMESTAMPTZ;
+UPDATE ports SET protected_until = COALESCE(created_at, NOW()) + INTERVAL '72 hours';
+ALTER TABLE ports ALTER COLUMN protected_until SET DEFAULT NOW() + INTERVAL '72 hours';
+ALTER TABLE ports ALTER COLUMN protected_until SET NOT NULL;
+
+-- Total units looted in a raid, so a port's losses over the last day can be
+-- capped without reading every report.
+ALTER TABLE raid_reports ADD COLUMN looted INTEGER NOT NULL DEFAULT 0;
+UPDATE raid_reports r SET looted = COALESCE((
+    SELECT SUM(value::integer) FROM jsonb_each_text(r.report->'captured')
+), 0);
+
+CREATE INDEX idx_raid_reports_port ON raid_reports(port_id, raided_at DESC);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_raid_reports_port;
+ALTER TABLE raid_reports DROP COLUMN looted;
+ALTER TABLE ports DROP COLUMN protected_until;
+-- +goose StatementEnd
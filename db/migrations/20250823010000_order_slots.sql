@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Only trade centers let an island keep orders on the market. Docks still
+-- give trade slots, but they no longer count towards open orders:
+--   order_slots            open market orders the island can hold
+ALTER TABLE building_effects DROP CONSTRAINT building_effects_effect_check;
+ALTER TABLE building_effects ADD CONSTRAINT building_effects_effect_check CHECK (effect IN (
+    'production_multiplier',
+    'build_time_reduction',
+    'defense_rating',
+    'crew_capacity',
+    'trade_slots',
+    'build_slots',
+    'protected_storage',
+    'order_slots'
+));
+
+INSERT INTO building_effects (building_type, level, effect, value)
+SELECT bt.type_name, lvl, 'order_slots', 2 * lvl
+FROM building_types bt
+CROSS JOIN generate_series(1, bt.max_level) AS lvl
+WHERE bt.type_name = 'trade_center';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM building_effects WHERE effect = 'order_slots';
+ALTER TABLE building_effects DROP CONSTRAINT building_effects_effect_check;
+ALTER TABLE building_effects ADD CONSTRAINT building_effects_effect_check CHECK (effect IN (
+    'production_multiplier',
+    'build_time_reduction',
+    'defense_rating',
+    'crew_capacity',
+    'trade_slots',
+    'build_slots',
+    'protected_storage'
+));
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Sailors a ship has aboard. Ships are built fully crewed and lose crew in
+-- battles and raids; their class's crew is the most they can carry.
+ALTER TABLE ships ADD COLUMN crew INTEGER;
+UPDATE ships s SET crew = sc.crew FROM ship_classes sc WHERE sc.name = s.ship_class;
+ALTER TABLE ships ALTER COLUMN crew SET NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE ships DROP COLUMN crew;
+-- +goose StatementEnd
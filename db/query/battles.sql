@@ -0,0 +1,40 @@
+-- name: CreateBattleReport :one
+INSERT INTO battle_reports (engagement_id, attacker_player_id, defender_player_id, attacker_fleet_id, defender_fleet_id, port_id, x, y, outcome, report)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING *;
+
+-- name: BattleReportExists :one
+SELECT EXISTS (SELECT 1 FROM battle_reports WHERE engagement_id = $1);
+
+-- name: GetBattleReport :one
+SELECT * FROM battle_reports WHERE id = $1;
+
+-- name: GetPlayerBattleReports :many
+SELECT * FROM battle_reports
+WHERE attacker_player_id = sqlc.arg(player_id) OR defender_player_id = sqlc.arg(player_id)
+ORDER BY fought_at DESC, id DESC
+LIMIT 50;
+
+-- name: GetHostileFleetsAt :many
+SELECT f.*
+FROM fleets f
+JOIN players p ON p.id = f.player_id
+JOIN faction_relations fr ON fr.faction_id = sqlc.arg(faction_id) AND fr.other_faction_id = p.faction
+WHERE f.status <> 'sailing'
+  AND f.x = sqlc.arg(x)
+  AND f.y = sqlc.arg(y)
+  AND f.player_id <> sqlc.arg(player_id)
+  AND fr.stance = 'hostile'
+ORDER BY f.id;
+
+-- name: AreFactionsHostile :one
+SELECT EXISTS (
+    SELECT 1 FROM faction_relations
+    WHERE faction_id = $1 AND other_faction_id = $2 AND stance = 'hostile'
+);
+
+-- name: UpdateShipDamage :exec
+UPDATE ships SET hull = $2, crew = $3 WHERE id = $1;
+
+-- name: DeleteShip :exec
+DELETE FROM ships WHERE id = $1;
@@ -0,0 +1,11 @@
+-- name: GetBuildingEffects :many
+SELECT * FROM building_effects WHERE building_type = $1 ORDER BY level, effect;
+
+-- name: GetPortBuildingEffects :many
+SELECT be.effect, SUM(be.value)::double precision AS value
+FROM buildings b
+JOIN building_effects be ON be.building_type = b.type
+    AND be.level = CASE WHEN b.under_construction THEN b.level - 1 ELSE b.level END
+WHERE b.port_id = $1 AND b.demolish_at IS NULL
+GROUP BY be.effect
+ORDER BY be.effect;
@@ -0,0 +1,14 @@
+-- name: GetAllBuildingCostCurves :many
+SELECT * FROM building_cost_curves ORDER BY building_type, resource_type;
+
+-- name: GetBuildingCostCurves :many
+SELECT * FROM building_cost_curves WHERE building_type = $1 ORDER BY resource_type;
+
+-- name: GetAllBuildingLevelCosts :many
+SELECT * FROM building_level_costs ORDER BY building_type, level, resource_type;
+
+-- name: GetBuildingLevelCosts :many
+SELECT * FROM building_level_costs WHERE building_type = $1 ORDER BY level, resource_type;
+
+-- name: GetBuildingLevelTimes :many
+SELECT * FROM building_level_times WHERE building_type = $1 ORDER BY level;
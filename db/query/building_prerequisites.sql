@@ -0,0 +1,41 @@
+-- name: GetAllBuildingPrerequisites :many
+SELECT * FROM building_prerequisites
+ORDER BY building_type, level, id;
+
+-- name: GetPortPrerequisiteStatus :many
+SELECT
+    bp.id,
+    bp.building_type,
+    bp.level,
+    bp.required_building,
+    bp.required_level,
+    bp.required_research,
+    (
+        (bp.required_building IS NULL OR EXISTS (
+            SELECT 1 FROM buildings b
+            WHERE b.port_id = $1
+            AND b.type = bp.required_building
+            AND b.demolish_at IS NULL
+            AND CASE WHEN b.under_construction THEN b.level - 1 ELSE b.level END >= bp.required_level
+        ))
+        AND (bp.required_research IS NULL OR EXISTS (
+            SELECT 1 FROM player_research pr
+            JOIN ports p ON p.player_id = pr.player_id
+            WHERE p.id = $1 AND pr.research_type = bp.required_research
+        ))
+    )::boolean AS met
+FROM building_prerequisites bp
+ORDER BY bp.building_type, bp.level, bp.id;
+
+-- name: GetAllResearchTypes :many
+SELECT * FROM research_types ORDER BY name;
+
+-- name: GetPlayerResearch :many
+SELECT * FROM player_research
+WHERE player_id = $1
+ORDER BY completed_at;
+
+-- name: GrantPlayerResearch :exec
+INSERT INTO player_research (player_id, research_type)
+VALUES ($1, $2)
+ON CONFLICT (player_id, research_type) DO NOTHING;
@@ -17,3 +17,31 @@ UPDATE buildings
 SET level = $2
 WHERE id = $1
 RETURNING *;
+
+-- name: DeleteBuilding :exec
+DELETE FROM buildings WHERE id = $1;
+
+-- name: RevertBuildingUpgrade :exec
+UPDATE buildings
+SET level = $2,
+    under_construction = FALSE,
+    construction_complete_at = NULL
+WHERE id = $1;
+
+-- name: AddBuildingInvestment :exec
+INSERT INTO building_investments (building_id, resource_type, amount)
+VALUES ($1, $2, $3)
+ON CONFLICT (building_id, resource_type) DO UPDATE SET amount = building_investments.amount + EXCLUDED.amount;
+
+-- name: GetBuildingInvestments :many
+SELECT * FROM building_investments WHERE building_id = $1 ORDER BY resource_type;
+
+-- name: MarkBuildingDemolishing :exec
+UPDATE buildings
+SET demolish_at = $2
+WHERE id = $1;
+
+-- name: SetBuildingStalled :exec
+UPDATE buildings
+SET stalled_resource = $2
+WHERE id = $1;
@@ -0,0 +1,65 @@
+-- name: CreateConstructionQueueItem :one
+INSERT INTO construction_queue (port_id, building_id, building_type, target_level, duration_seconds, position)
+VALUES ($1, $2, $3, $4, $5, (
+    SELECT COALESCE(MAX(cq.position), 0) + 1 FROM construction_queue cq WHERE cq.port_id = $1
+))
+RETURNING *;
+
+-- name: GetConstructionQueueItem :one
+SELECT * FROM construction_queue WHERE id = $1;
+
+-- name: LockConstructionQueueItem :one
+SELECT * FROM construction_queue WHERE id = $1 FOR UPDATE;
+
+-- name: GetConstructionQueueItemForBuilding :one
+SELECT * FROM construction_queue WHERE building_id = $1;
+
+-- name: GetPortConstructionQueue :many
+SELECT * FROM construction_queue
+WHERE port_id = $1
+ORDER BY position;
+
+-- name: GetNextQueuedConstruction :one
+SELECT * FROM construction_queue
+WHERE port_id = $1 AND status = 'queued'
+ORDER BY position
+LIMIT 1;
+
+-- name: CountActiveConstructions :one
+SELECT COUNT(*) FROM construction_queue
+WHERE port_id = $1 AND status = 'active';
+
+-- name: StartConstructionQueueItem :exec
+UPDATE construction_queue
+SET status = 'active',
+    building_id = $2,
+    started_at = $3,
+    finishes_at = $4
+WHERE id = $1;
+
+-- name: GetDueConstructions :many
+SELECT * FROM construction_queue
+WHERE status = 'active' AND finishes_at <= NOW()
+ORDER BY finishes_at;
+
+-- name: UpdateConstructionQueuePosition :exec
+UPDATE construction_queue
+SET position = $2
+WHERE id = $1;
+
+-- name: DeleteConstructionQueueItem :exec
+DELETE FROM construction_queue WHERE id = $1;
+
+-- name: AddConstructionQueueCost :exec
+INSERT INTO construction_queue_costs (queue_item_id, resource_type, amount)
+VALUES ($1, $2, $3);
+
+-- name: GetConstructionQueueCosts :many
+SELECT * FROM construction_queue_costs WHERE queue_item_id = $1 ORDER BY resource_type;
+
+-- name: GetPortConstructionQueueCosts :many
+SELECT c.queue_item_id, c.resource_type, c.amount
+FROM construction_queue_costs c
+JOIN construction_queue cq ON cq.id = c.queue_item_id
+WHERE cq.port_id = $1
+ORDER BY c.queue_item_id, c.resource_type;
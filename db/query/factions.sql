@@ -23,4 +23,23 @@ SELECT
     f.name as faction_name
 FROM players p
 JOIN factions f ON p.faction = f.id
-WHERE p.id = $1;
\ No newline at end of file
+WHERE p.id = $1;
+
+-- name: GetFactionStances :many
+SELECT * FROM faction_stances ORDER BY rate_bonus DESC;
+
+-- name: GetFactionRelations :many
+SELECT * FROM faction_relations ORDER BY faction_id, other_faction_id;
+
+-- name: GetTradeStance :one
+SELECT * FROM faction_stances
+WHERE stance = COALESCE(
+    (SELECT fr.stance FROM faction_relations fr
+     WHERE fr.faction_id = sqlc.arg(post_faction) AND fr.other_faction_id = sqlc.arg(player_faction)),
+    CASE WHEN sqlc.arg(post_faction) = sqlc.arg(player_faction) THEN 'own' ELSE 'neutral' END
+);
+
+-- name: AddFactionTreasury :exec
+UPDATE factions
+SET treasury = treasury + $2
+WHERE id = $1;
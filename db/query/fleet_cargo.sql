@@ -0,0 +1,23 @@
+-- name: GetFleetCargo :many
+SELECT * FROM fleet_cargo
+WHERE fleet_id = $1 AND amount > 0
+ORDER BY resource_type;
+
+-- name: AddFleetCargo :exec
+INSERT INTO fleet_cargo (fleet_id, resource_type, amount)
+VALUES ($1, $2, $3)
+ON CONFLICT (fleet_id, resource_type)
+DO UPDATE SET amount = fleet_cargo.amount + EXCLUDED.amount;
+
+-- name: RemoveFleetCargo :execrows
+UPDATE fleet_cargo
+SET amount = amount - $3
+WHERE fleet_id = $1 AND resource_type = $2 AND amount >= $3;
+
+-- name: DeleteEmptyFleetCargo :exec
+DELETE FROM fleet_cargo WHERE fleet_id = $1 AND amount = 0;
+
+-- name: ClearFleetCargo :many
+DELETE FROM fleet_cargo
+WHERE fleet_id = $1
+RETURNING *;
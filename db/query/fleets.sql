@@ -0,0 +1,81 @@
+-- name: CreateFleet :one
+INSERT INTO fleets (player_id, name, status, port_id, x, y)
+VALUES ($1, $2, 'docked', $3, $4, $5)
+RETURNING *;
+
+-- name: GetFleet :one
+SELECT * FROM fleets WHERE id = $1;
+
+-- name: LockFleet :one
+SELECT * FROM fleets WHERE id = $1 FOR UPDATE;
+
+-- name: GetPlayerFleets :many
+SELECT * FROM fleets WHERE player_id = $1 ORDER BY id;
+
+-- name: GetFleetsAtSea :many
+SELECT * FROM fleets WHERE status <> 'docked' ORDER BY id;
+
+-- name: AssignShipToFleet :execrows
+UPDATE ships
+SET fleet_id = $2
+WHERE id = $1 AND player_id = $3 AND port_id = $4 AND status = 'docked' AND fleet_id IS NULL;
+
+-- name: GetFleetShips :many
+SELECT s.*, sc.speed, sc.cargo_capacity, sc.hull AS max_hull, sc.cannons, sc.crew AS max_crew
+FROM ships s
+JOIN ship_classes sc ON sc.name = s.ship_class
+WHERE s.fleet_id = $1
+ORDER BY s.id;
+
+-- name: ReleaseFleetShips :exec
+UPDATE ships SET fleet_id = NULL WHERE fleet_id = $1;
+
+-- name: DeleteFleet :exec
+DELETE FROM fleets WHERE id = $1;
+
+-- name: DispatchFleet :exec
+UPDATE fleets
+SET status = 'sailing',
+    port_id = NULL,
+    origin_x = $2,
+    origin_y = $3,
+    target_x = $4,
+    target_y = $5,
+    target_port_id = $6,
+    departed_at = $7,
+    arrives_at = $8,
+    raiding = $9
+WHERE id = $1;
+
+-- name: ArriveFleet :exec
+UPDATE fleets
+SET status = $2,
+    port_id = $3,
+    x = $4,
+    y = $5,
+    origin_x = NULL,
+    origin_y = NULL,
+    target_x = NULL,
+    target_y = NULL,
+    target_port_id = NULL,
+    departed_at = NULL,
+    arrives_at = NULL,
+    raiding = FALSE
+WHERE id = $1;
+
+-- name: SetFleetShipsAtSea :exec
+UPDATE ships
+SET status = 'at_sea',
+    port_id = NULL
+WHERE fleet_id = $1;
+
+-- name: DockFleetShips :exec
+UPDATE ships
+SET status = 'docked',
+    port_id = $2
+WHERE fleet_id = $1;
+
+-- name: DeleteEmptyPlayerFleets :exec
+DELETE FROM fleets f
+WHERE f.player_id = $1
+  AND NOT EXISTS (SELECT 1 FROM ships s WHERE s.fleet_id = f.id);
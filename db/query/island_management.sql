@@ -10,17 +10,17 @@ SELECT * FROM building_types WHERE category = $1 ORDER BY type_name;
 
 -- Building Production Queries
 -- name: GetProductionRatesForBuilding :many
-SELECT bp.resource_type, bp.production_rate 
+SELECT bp.resource_type, bp.flow, bp.production_per_hour 
 FROM building_production bp
 JOIN building_types bt ON bp.building_type_id = bt.id
 WHERE bt.type_name = $1 AND bp.level = $2;
 
 -- name: GetAllProductionForBuildingType :many
-SELECT bp.level, bp.resource_type, bp.production_rate
+SELECT bp.level, bp.resource_type, bp.flow, bp.production_per_hour
 FROM building_production bp
 JOIN building_types bt ON bp.building_type_id = bt.id
 WHERE bt.type_name = $1
-ORDER BY bp.level, bp.resource_type;
+ORDER BY bp.level, bp.flow, bp.resource_type;
 
 -- Island/Port Management Queries
 -- name: GetPortWithResources :one
@@ -31,17 +31,9 @@ SELECT
     p.x,
     p.y,
     p.created_at as port_created_at,
-    r.wood,
-    r.iron,
-    r.rum,
-    r.sugar,
-    r.tobacco,
-    r.cotton,
-    r.coffee,
-    r.grain,
-    r.gold,
-    r.silver,
-    r.updated_at as resources_updated_at
+    p.protected_until,
+    r.updated_at as resources_updated_at,
+    r.settled_at as resources_settled_at
 FROM ports p
 LEFT JOIN resources r ON p.id = r.port_id
 WHERE p.id = $1;
@@ -54,7 +46,8 @@ SELECT
     b.level,
     b.under_construction,
     b.construction_complete_at,
-    b.last_production_at,
+    b.demolish_at,
+    b.stalled_resource,
     b.created_at,
     bt.display_name,
     bt.description,
@@ -73,17 +66,9 @@ RETURNING *;
 -- name: CompleteBuildingConstruction :exec
 UPDATE buildings 
 SET under_construction = FALSE, 
-    construction_complete_at = NULL,
-    last_production_at = NOW()
+    construction_complete_at = NULL
 WHERE id = $1;
 
--- name: GetBuildingsUnderConstruction :many
-SELECT b.*, bt.display_name, bt.base_build_time
-FROM buildings b
-JOIN building_types bt ON b.type = bt.type_name
-WHERE b.under_construction = TRUE 
-AND b.construction_complete_at <= NOW();
-
 -- name: UpgradeBuilding :exec
 UPDATE buildings 
 SET level = level + 1,
@@ -95,64 +80,85 @@ WHERE buildings.id = $1 AND buildings.level < (
     )
 );
 
--- name: GetBuildingsReadyForProduction :many
-SELECT 
-    b.id,
-    b.port_id,
-    b.type,
-    b.level,
-    b.last_production_at
-FROM buildings b
-WHERE b.under_construction = FALSE 
-AND (b.last_production_at IS NULL OR b.last_production_at < $1);
-
--- name: UpdateBuildingLastProduction :exec
-UPDATE buildings 
-SET last_production_at = NOW()
-WHERE id = $1;
-
 -- Resource Management Queries
 -- name: InitializePortResources :exec
 INSERT INTO resources (port_id) 
 VALUES ($1)
 ON CONFLICT (port_id) DO NOTHING;
 
--- name: AddResourcesToPort :exec
-UPDATE resources 
-SET 
-    wood = wood + $2,
-    iron = iron + $3,
-    rum = rum + $4,
-    sugar = sugar + $5,
-    tobacco = tobacco + $6,
-    cotton = cotton + $7,
-    coffee = coffee + $8,
-    grain = grain + $9,
-    gold = gold + $10,
-    silver = silver + $11,
+-- name: GetPortResources :many
+SELECT * FROM port_resources
+WHERE port_id = $1
+ORDER BY resource_type;
+
+-- name: AddPortResource :exec
+INSERT INTO port_resources (port_id, resource_type, amount)
+VALUES ($1, $2, $3)
+ON CONFLICT (port_id, resource_type) DO UPDATE
+SET amount = port_resources.amount + EXCLUDED.amount,
+    updated_at = NOW();
+
+-- name: ConsumePortResource :execrows
+UPDATE port_resources
+SET amount = amount - $3,
     updated_at = NOW()
+WHERE port_id = $1 AND resource_type = $2 AND amount >= $3;
+
+-- name: GetAllResourceTypes :many
+SELECT * FROM resource_types ORDER BY sort_order, name;
+
+-- name: LockPortResources :one
+SELECT * FROM resources WHERE port_id = $1 FOR UPDATE;
+
+-- name: MarkResourcesSettled :exec
+UPDATE resources
+SET settled_at = $2
 WHERE port_id = $1;
 
--- name: ConsumeResourcesFromPort :exec
-UPDATE resources 
-SET 
-    wood = GREATEST(0, wood - $2),
-    iron = GREATEST(0, iron - $3),
-    rum = GREATEST(0, rum - $4),
-    sugar = GREATEST(0, sugar - $5),
-    tobacco = GREATEST(0, tobacco - $6),
-    cotton = GREATEST(0, cotton - $7),
-    coffee = GREATEST(0, coffee - $8),
-    grain = GREATEST(0, grain - $9),
-    gold = GREATEST(0, gold - $10),
-    silver = GREATEST(0, silver - $11),
-    updated_at = NOW()
+-- Port Production Queries
+-- name: GetPortProduction :many
+SELECT * FROM port_production WHERE port_id = $1 ORDER BY resource_type;
+
+-- name: CalculatePortProductionRates :many
+SELECT bp.resource_type,
+    SUM(CASE WHEN bp.flow = 'output' THEN bp.production_per_hour ELSE -bp.production_per_hour END)::integer AS rate_per_hour
+FROM buildings b
+JOIN building_types bt ON b.type = bt.type_name
+JOIN building_production bp ON bp.building_type_id = bt.id AND bp.level = b.level
+WHERE b.port_id = $1 AND b.under_construction = FALSE AND b.demolish_at IS NULL
+GROUP BY bp.resource_type;
+
+-- name: GetPortBuildingFlows :many
+SELECT b.id AS building_id, b.stalled_resource, bp.resource_type, bp.flow, bp.production_per_hour
+FROM buildings b
+JOIN building_types bt ON b.type = bt.type_name
+JOIN building_production bp ON bp.building_type_id = bt.id AND bp.level = b.level
+WHERE b.port_id = $1 AND b.under_construction = FALSE AND b.demolish_at IS NULL
+ORDER BY b.id, bp.flow, bp.resource_type;
+
+-- name: ClearPortProductionRates :exec
+UPDATE port_production
+SET rate_per_hour = 0
 WHERE port_id = $1;
 
--- name: CheckResourceAvailability :one
+-- name: UpsertPortProductionRate :exec
+INSERT INTO port_production (port_id, resource_type, rate_per_hour)
+VALUES ($1, $2, $3)
+ON CONFLICT (port_id, resource_type) DO UPDATE SET rate_per_hour = EXCLUDED.rate_per_hour;
+
+-- name: UpdatePortProductionCarry :exec
+UPDATE port_production
+SET carry = $3
+WHERE port_id = $1 AND resource_type = $2;
+
+-- Storage Queries
+-- name: GetPortStorageCapacity :many
 SELECT 
-    (wood >= $2) as has_wood,
-    (iron >= $3) as has_iron,
-    (gold >= $4) as has_gold
-FROM resources 
-WHERE port_id = $1;
\ No newline at end of file
+    rt.name AS resource_type,
+    (rt.base_capacity + rt.capacity_per_warehouse_level * COALESCE((
+        SELECT SUM(CASE WHEN b.under_construction THEN b.level - 1 ELSE b.level END)
+        FROM buildings b
+        WHERE b.port_id = $1 AND b.type = 'warehouse'
+    ), 0))::integer AS capacity
+FROM resource_types rt
+ORDER BY rt.sort_order, rt.name;
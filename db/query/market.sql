@@ -0,0 +1,82 @@
+-- name: LockMarket :exec
+SELECT pg_advisory_xact_lock(hashtext('market'));
+
+-- name: CreateMarketOrder :one
+INSERT INTO market_orders (player_id, port_id, side, resource_type, price_per_unit, quantity, remaining, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $6, $7)
+RETURNING *;
+
+-- name: GetMarketOrder :one
+SELECT * FROM market_orders WHERE id = $1;
+
+-- name: LockMarketOrder :one
+SELECT * FROM market_orders WHERE id = $1 FOR UPDATE;
+
+-- name: CountOpenPortOrders :one
+SELECT COUNT(*)::integer AS open_orders
+FROM market_orders
+WHERE port_id = $1 AND status = 'open';
+
+-- name: GetPlayerMarketOrders :many
+SELECT * FROM market_orders
+WHERE player_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT 100;
+
+-- name: GetMatchingSellOrders :many
+SELECT * FROM market_orders
+WHERE resource_type = sqlc.arg(resource_type)
+  AND side = 'sell'
+  AND status = 'open'
+  AND expires_at > sqlc.arg(now)
+  AND price_per_unit <= sqlc.arg(max_price)
+  AND player_id <> sqlc.arg(player_id)
+ORDER BY price_per_unit, created_at, id
+FOR UPDATE;
+
+-- name: GetMatchingBuyOrders :many
+SELECT * FROM market_orders
+WHERE resource_type = sqlc.arg(resource_type)
+  AND side = 'buy'
+  AND status = 'open'
+  AND expires_at > sqlc.arg(now)
+  AND price_per_unit >= sqlc.arg(min_price)
+  AND player_id <> sqlc.arg(player_id)
+ORDER BY price_per_unit DESC, created_at, id
+FOR UPDATE;
+
+-- name: FillMarketOrder :exec
+UPDATE market_orders
+SET remaining = remaining - sqlc.arg(quantity),
+    status = CASE WHEN remaining - sqlc.arg(quantity) = 0 THEN 'filled' ELSE status END,
+    closed_at = CASE WHEN remaining - sqlc.arg(quantity) = 0 THEN NOW() ELSE closed_at END
+WHERE id = sqlc.arg(id);
+
+-- name: CloseMarketOrder :exec
+UPDATE market_orders
+SET status = $2,
+    closed_at = NOW()
+WHERE id = $1 AND status = 'open';
+
+-- name: CreateMarketTrade :one
+INSERT INTO market_trades (resource_type, buy_order_id, sell_order_id, quantity, price_per_unit)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetOrderBook :many
+SELECT side, price_per_unit, SUM(remaining)::integer AS quantity, COUNT(*)::integer AS orders
+FROM market_orders
+WHERE resource_type = sqlc.arg(resource_type) AND status = 'open' AND expires_at > sqlc.arg(now)
+GROUP BY side, price_per_unit
+ORDER BY side, price_per_unit;
+
+-- name: GetRecentMarketTrades :many
+SELECT * FROM market_trades
+WHERE resource_type = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2;
+
+-- name: GetPortTradeCenterLevel :one
+SELECT COALESCE(MAX(CASE WHEN under_construction THEN level - 1 ELSE level END), 0)::integer AS level
+FROM buildings
+WHERE port_id = $1 AND type = 'trade_center' AND demolish_at IS NULL;
@@ -0,0 +1,41 @@
+-- name: GetNpcPrices :many
+SELECT p.*
+FROM npc_market_prices p
+JOIN resource_types rt ON rt.name = p.resource_type
+ORDER BY rt.sort_order, p.resource_type;
+
+-- name: LockNpcPrice :one
+SELECT * FROM npc_market_prices WHERE resource_type = $1 FOR UPDATE;
+
+-- name: LockNpcPrices :many
+SELECT * FROM npc_market_prices ORDER BY resource_type FOR UPDATE;
+
+-- name: AddNpcVolume :exec
+UPDATE npc_market_prices
+SET pending_volume = pending_volume + $2
+WHERE resource_type = $1;
+
+-- name: UpdateNpcPrice :exec
+UPDATE npc_market_prices
+SET price = $2,
+    pending_volume = 0,
+    updated_at = $3
+WHERE resource_type = $1;
+
+-- name: InsertNpcPriceHistory :exec
+INSERT INTO npc_price_history (resource_type, price, volume, recorded_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: GetNpcPriceHistorySince :many
+SELECT * FROM npc_price_history
+WHERE recorded_at >= $1
+ORDER BY resource_type, recorded_at;
+
+-- name: DeleteNpcPriceHistoryBefore :exec
+DELETE FROM npc_price_history WHERE recorded_at < $1;
+
+-- name: GetNpcTradingPosts :many
+SELECT * FROM npc_trading_posts ORDER BY id;
+
+-- name: GetNpcTradingPost :one
+SELECT * FROM npc_trading_posts WHERE id = $1;
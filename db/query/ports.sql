@@ -12,4 +12,8 @@ RETURNING *;
 -- name: CreatePlayerIsland :one
 INSERT INTO ports (player_id, name, x, y, island_type, starting_resources_initialized)
 VALUES ($1, $2, $3, $4, 'tropical', TRUE)
-RETURNING *; 
\ No newline at end of file
+RETURNING *;
+
+-- name: EndPortProtection :exec
+UPDATE ports SET protected_until = NOW()
+WHERE player_id = $1 AND protected_until > NOW();
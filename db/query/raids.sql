@@ -0,0 +1,35 @@
+-- name: CreateRaidReport :one
+INSERT INTO raid_reports (raid_id, attacker_player_id, defender_player_id, fleet_id, port_id, defense_rating, outcome, report, looted)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: RaidReportExists :one
+SELECT EXISTS (SELECT 1 FROM raid_reports WHERE raid_id = $1);
+
+-- name: GetRaidReport :one
+SELECT * FROM raid_reports WHERE id = $1;
+
+-- name: GetPlayerRaidReports :many
+SELECT * FROM raid_reports
+WHERE attacker_player_id = sqlc.arg(player_id) OR defender_player_id = sqlc.arg(player_id)
+ORDER BY raided_at DESC, id DESC
+LIMIT 50;
+
+-- name: HasRaidedPortSince :one
+SELECT EXISTS (
+    SELECT 1 FROM raid_reports
+    WHERE attacker_player_id = $1 AND port_id = $2 AND raided_at > $3
+);
+
+-- name: GetPortRaidLossesSince :one
+SELECT COALESCE(SUM(looted), 0)::integer AS looted
+FROM raid_reports
+WHERE port_id = $1 AND raided_at > $2;
+
+-- name: GetPortDefenders :many
+SELECT s.id, s.name, s.ship_class, s.hull, s.crew, sc.hull AS max_hull, sc.cannons, sc.crew AS max_crew
+FROM ships s
+JOIN ship_classes sc ON sc.name = s.ship_class
+WHERE s.port_id = $1 AND s.player_id = $2 AND s.status = 'docked'
+ORDER BY s.id
+FOR UPDATE OF s;
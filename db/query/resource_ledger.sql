@@ -0,0 +1,21 @@
+-- name: InsertLedgerEntry :exec
+INSERT INTO resource_ledger (port_id, resource_type, delta, reason, reference_id)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetPortLedger :many
+SELECT * FROM resource_ledger
+WHERE port_id = sqlc.arg(port_id)
+AND (sqlc.narg(resource_type)::text IS NULL OR resource_type = sqlc.narg(resource_type))
+AND (sqlc.narg(reason)::text IS NULL OR reason = sqlc.narg(reason))
+AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since))
+AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: CountPortLedger :one
+SELECT COUNT(*) FROM resource_ledger
+WHERE port_id = sqlc.arg(port_id)
+AND (sqlc.narg(resource_type)::text IS NULL OR resource_type = sqlc.narg(resource_type))
+AND (sqlc.narg(reason)::text IS NULL OR reason = sqlc.narg(reason))
+AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since))
+AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until));
@@ -0,0 +1,33 @@
+-- name: GetAllShipClasses :many
+SELECT * FROM ship_classes ORDER BY sort_order, name;
+
+-- name: GetShipClass :one
+SELECT * FROM ship_classes WHERE name = $1;
+
+-- name: GetAllShipClassCosts :many
+SELECT * FROM ship_class_costs ORDER BY ship_class, resource_type;
+
+-- name: GetShipClassCosts :many
+SELECT * FROM ship_class_costs WHERE ship_class = $1 ORDER BY resource_type;
+
+-- name: GetPortShipyardLevel :one
+SELECT COALESCE(MAX(CASE WHEN under_construction THEN level - 1 ELSE level END), 0)::integer AS level
+FROM buildings
+WHERE port_id = $1 AND type = 'shipyard' AND demolish_at IS NULL;
+
+-- name: CreateShip :one
+INSERT INTO ships (player_id, port_id, ship_class, name, status, hull, crew, completes_at)
+VALUES ($1, $2, $3, $4, 'under_construction', $5, $6, $7)
+RETURNING *;
+
+-- name: GetShip :one
+SELECT * FROM ships WHERE id = $1;
+
+-- name: CompleteShipConstruction :execrows
+UPDATE ships
+SET status = 'docked',
+    completes_at = NULL
+WHERE id = $1 AND status = 'under_construction';
+
+-- name: GetPlayerShips :many
+SELECT * FROM ships WHERE player_id = $1 ORDER BY id;
@@ -38,6 +38,19 @@ func (s *Service) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// OptionalAuth adds the user to the request context when a valid token is
+// sent, and lets anonymous requests through. A token that is sent but
+// invalid is still rejected.
+func (s *Service) OptionalAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		s.RequireAuth(next)(w, r)
+	}
+}
+
 func GetUserIDFromContext(ctx context.Context) (int32, bool) {
 	userID, ok := ctx.Value(UserIDKey).(int32)
 	return userID, ok
@@ -0,0 +1,278 @@
+// Package combat resolves naval engagements. Resolution is a pure function
+// of the two sides and a seed, so the same engagement always plays out the
+// same way and a battle can be replayed from its report.
+package combat
+
+import (
+	"math"
+	"math/rand/v2"
+)
+
+// Outcome is how an engagement ended.
+type Outcome string
+
+const (
+	OutcomeAttacker Outcome = "attacker"
+	OutcomeDefender Outcome = "defender"
+	OutcomeDraw     Outcome = "draw"
+)
+
+const (
+	// MaxRounds is how long an engagement lasts before both sides break
+	// off.
+	MaxRounds = 12
+
+	// StartingMorale is every side's morale when the first shot is fired.
+	StartingMorale = 100.0
+
+	// BreakMorale is the morale at which a side strikes its colours.
+	BreakMorale = 25.0
+
+	// hitChance is how often a fully crewed gun at full morale hits.
+	hitChance = 0.6
+
+	// Each hit does minHitDamage to maxHitDamage damage to a hull.
+	minHitDamage = 2
+	maxHitDamage = 6
+
+	// moraleLossPerHull is the morale a side loses for taking damage equal
+	// to its whole starting hull, and moraleLossPerSinking what it loses for
+	// each ship sunk.
+	moraleLossPerHull    = 120.0
+	moraleLossPerSinking = 15.0
+)
+
+// Ship is a ship going into battle. MaxHull and MaxCrew are what its class
+// has when it is in perfect condition.
+type Ship struct {
+	ID      int32  `json:"id"`
+	Name    string `json:"name"`
+	Class   string `json:"class"`
+	Hull    int32  `json:"hull"`
+	MaxHull int32  `json:"max_hull"`
+	Cannons int32  `json:"cannons"`
+	Crew    int32  `json:"crew"`
+	MaxCrew int32  `json:"max_crew"`
+}
+
+// Side is one side of an engagement.
+type Side struct {
+	Ships []Ship
+}
+
+// ShipResult is how a ship came out of an engagement.
+type ShipResult struct {
+	ID        int32  `json:"id"`
+	Name      string `json:"name"`
+	Class     string `json:"class"`
+	StartHull int32  `json:"start_hull"`
+	Hull      int32  `json:"hull"`
+	StartCrew int32  `json:"start_crew"`
+	Crew      int32  `json:"crew"`
+	Sunk      bool   `json:"sunk"`
+}
+
+// SideReport is how one side came out of an engagement.
+type SideReport struct {
+	Ships       []ShipResult `json:"ships"`
+	DamageDealt int32        `json:"damage_dealt"`
+	DamageTaken int32        `json:"damage_taken"`
+	Morale      float64      `json:"morale"`
+	Struck      bool         `json:"struck"`
+}
+
+// Afloat reports whether any of the side's ships survived.
+func (r SideReport) Afloat() bool {
+	for _, ship := range r.Ships {
+		if !ship.Sunk {
+			return true
+		}
+	}
+	return false
+}
+
+// Round is what happened in one round of fire. Both sides fire at the same
+// time, so a ship sunk in a round still gets its broadside off.
+type Round struct {
+	Number         int     `json:"number"`
+	AttackerDamage int32   `json:"attacker_damage"`
+	DefenderDamage int32   `json:"defender_damage"`
+	AttackerMorale float64 `json:"attacker_morale"`
+	DefenderMorale float64 `json:"defender_morale"`
+	Sunk           []int32 `json:"sunk"`
+}
+
+// Report is the full account of an engagement. Captured is the cargo the
+// winner took, filled in when the outcome is applied since the resolver
+// knows nothing about cargo.
+type Report struct {
+	Seed     uint64           `json:"seed"`
+	Outcome  Outcome          `json:"outcome"`
+	Rounds   []Round          `json:"rounds"`
+	Attacker SideReport       `json:"attacker"`
+	Defender SideReport       `json:"defender"`
+	Captured map[string]int32 `json:"captured"`
+}
+
+// fighter is a side's state during an engagement.
+type fighter struct {
+	ships     []ShipResult
+	maxHull   []int32
+	maxCrew   []int32
+	cannons   []int32
+	startHull int32
+	morale    float64
+	dealt     int32
+	taken     int32
+}
+
+func newFighter(side Side) *fighter {
+	f := &fighter{morale: StartingMorale}
+	for _, ship := range side.Ships {
+		if ship.Hull <= 0 {
+			continue
+		}
+		f.ships = append(f.ships, ShipResult{
+			ID:        ship.ID,
+			Name:      ship.Name,
+			Class:     ship.Class,
+			StartHull: ship.Hull,
+			Hull:      ship.Hull,
+			StartCrew: ship.Crew,
+			Crew:      ship.Crew,
+		})
+		f.maxHull = append(f.maxHull, max(ship.MaxHull, 1))
+		f.maxCrew = append(f.maxCrew, max(ship.MaxCrew, 1))
+		f.cannons = append(f.cannons, ship.Cannons)
+		f.startHull += ship.Hull
+	}
+	return f
+}
+
+func (f *fighter) afloat() []int {
+	var afloat []int
+	for i, ship := range f.ships {
+		if !ship.Sunk {
+			afloat = append(afloat, i)
+		}
+	}
+	return afloat
+}
+
+func (f *fighter) broken() bool {
+	return f.morale <= BreakMorale || len(f.afloat()) == 0
+}
+
+// broadside works out the damage each of the side's ships does this round
+// and which enemy ship takes it. Undermanned guns fire less often and low
+// morale spoils the aim.
+func (f *fighter) broadside(rng *rand.Rand, enemy *fighter) []int32 {
+	damage := make([]int32, len(enemy.ships))
+	targets := enemy.afloat()
+	if len(targets) == 0 {
+		return damage
+	}
+
+	accuracy := hitChance * (0.5 + f.morale/(2*StartingMorale))
+	for _, i := range f.afloat() {
+		ship := f.ships[i]
+		manned := float64(ship.Crew) / float64(f.maxCrew[i])
+		guns := int(math.Ceil(float64(f.cannons[i]) * min(manned, 1)))
+
+		target := targets[rng.IntN(len(targets))]
+		for range guns {
+			if rng.Float64() < accuracy {
+				damage[target] += int32(minHitDamage + rng.IntN(maxHitDamage-minHitDamage+1))
+			}
+		}
+	}
+	return damage
+}
+
+// takeFire applies a round of damage and returns the total taken and the
+// ships it sank. Crews suffer in proportion to the damage to their ship.
+func (f *fighter) takeFire(damage []int32) (int32, []int32) {
+	var total int32
+	var sunk []int32
+	for i, d := range damage {
+		if d == 0 {
+			continue
+		}
+
+		ship := &f.ships[i]
+		d = min(d, ship.Hull)
+		total += d
+
+		casualties := int32(math.Ceil(float64(d) * float64(f.maxCrew[i]) / float64(f.maxHull[i]) / 2))
+		ship.Crew = max(ship.Crew-casualties, 0)
+		ship.Hull -= d
+
+		if ship.Hull <= 0 {
+			ship.Hull = 0
+			ship.Sunk = true
+			sunk = append(sunk, ship.ID)
+		}
+	}
+
+	f.taken += total
+	if f.startHull > 0 {
+		f.morale -= moraleLossPerHull * float64(total) / float64(f.startHull)
+	}
+	f.morale -= moraleLossPerSinking * float64(len(sunk))
+	f.morale = max(f.morale, 0)
+	return total, sunk
+}
+
+func (f *fighter) report() SideReport {
+	ships := f.ships
+	if ships == nil {
+		ships = []ShipResult{}
+	}
+	return SideReport{
+		Ships:       ships,
+		DamageDealt: f.dealt,
+		DamageTaken: f.taken,
+		Morale:      math.Round(f.morale*10) / 10,
+		Struck:      f.morale <= BreakMorale && len(f.afloat()) > 0,
+	}
+}
+
+// Resolve fights an engagement between two sides in rounds until one side
+// is sunk or strikes its colours, or MaxRounds have passed. The same sides
+// and seed always give the same report.
+func Resolve(attacker, defender Side, seed uint64) Report {
+	rng := rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+	a, d := newFighter(attacker), newFighter(defender)
+
+	report := Report{Seed: seed, Rounds: []Round{}, Captured: map[string]int32{}}
+	for number := 1; number <= MaxRounds && !a.broken() && !d.broken(); number++ {
+		atDefender := a.broadside(rng, d)
+		atAttacker := d.broadside(rng, a)
+
+		dealtByAttacker, defenderSunk := d.takeFire(atDefender)
+		dealtByDefender, attackerSunk := a.takeFire(atAttacker)
+		a.dealt += dealtByAttacker
+		d.dealt += dealtByDefender
+
+		report.Rounds = append(report.Rounds, Round{
+			Number:         number,
+			AttackerDamage: dealtByAttacker,
+			DefenderDamage: dealtByDefender,
+			AttackerMorale: math.Round(a.morale*10) / 10,
+			DefenderMorale: math.Round(d.morale*10) / 10,
+			Sunk:           append(append([]int32{}, defenderSunk...), attackerSunk...),
+		})
+	}
+
+	report.Outcome = OutcomeDraw
+	switch {
+	case d.broken() && !a.broken():
+		report.Outcome = OutcomeAttacker
+	case a.broken() && !d.broken():
+		report.Outcome = OutcomeDefender
+	}
+
+	report.Attacker = a.report()
+	report.Defender = d.report()
+	return report
+}
@@ -0,0 +1,67 @@
+package combat
+
+import (
+	"reflect"
+	"testing"
+)
+
+func sloop(id int32) Ship {
+	return Ship{ID: id, Name: "Sloop", Class: "sloop", Hull: 60, MaxHull: 60, Cannons: 8, Crew: 30, MaxCrew: 30}
+}
+
+func evenSides() (Side, Side) {
+	return Side{Ships: []Ship{sloop(1), sloop(2)}}, Side{Ships: []Ship{sloop(3), sloop(4)}}
+}
+
+func TestResolveIsDeterministic(t *testing.T) {
+	attacker, defender := evenSides()
+
+	for seed := uint64(0); seed < 50; seed++ {
+		first := Resolve(attacker, defender, seed)
+		second := Resolve(attacker, defender, seed)
+
+		if !reflect.DeepEqual(first, second) {
+			t.Fatalf("seed %d: reports differ\nfirst:  %+v\nsecond: %+v", seed, first, second)
+		}
+		if first.Seed != seed {
+			t.Fatalf("seed %d: report has seed %d", seed, first.Seed)
+		}
+	}
+}
+
+func TestResolveDoesNotChangeSides(t *testing.T) {
+	attacker, defender := evenSides()
+	wantAttacker, wantDefender := evenSides()
+
+	Resolve(attacker, defender, 7)
+
+	if !reflect.DeepEqual(attacker, wantAttacker) || !reflect.DeepEqual(defender, wantDefender) {
+		t.Fatal("Resolve modified the sides it was given")
+	}
+}
+
+func TestResolveSeedChangesOutcome(t *testing.T) {
+	attacker, defender := evenSides()
+
+	outcomes := map[Outcome]bool{}
+	for seed := uint64(0); seed < 200; seed++ {
+		outcomes[Resolve(attacker, defender, seed).Outcome] = true
+	}
+
+	if len(outcomes) < 2 {
+		t.Fatalf("evenly matched sides always ended in %v over 200 seeds", outcomes)
+	}
+}
+
+func TestResolveEmptyDefender(t *testing.T) {
+	attacker, _ := evenSides()
+
+	report := Resolve(attacker, Side{}, 1)
+
+	if report.Outcome != OutcomeAttacker {
+		t.Fatalf("outcome = %s, want %s", report.Outcome, OutcomeAttacker)
+	}
+	if len(report.Rounds) != 0 {
+		t.Fatalf("fought %d rounds against nobody", len(report.Rounds))
+	}
+}
@@ -7,12 +7,31 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const addBuildingInvestment = `-- name: AddBuildingInvestment :exec
+INSERT INTO building_investments (building_id, resource_type, amount)
+VALUES ($1, $2, $3)
+ON CONFLICT (building_id, resource_type) DO UPDATE SET amount = building_investments.amount + EXCLUDED.amount
+`
+
+type AddBuildingInvestmentParams struct {
+	BuildingID   int32
+	ResourceType string
+	Amount       int32
+}
+
+func (q *Queries) AddBuildingInvestment(ctx context.Context, arg AddBuildingInvestmentParams) error {
+	_, err := q.db.Exec(ctx, addBuildingInvestment, arg.BuildingID, arg.ResourceType, arg.Amount)
+	return err
+}
+
 const createBuilding = `-- name: CreateBuilding :one
 INSERT INTO buildings (port_id, type)
 VALUES ($1, $2)
-RETURNING id, port_id, type, level, created_at, under_construction, construction_complete_at, last_production_at
+RETURNING id, port_id, type, level, created_at, under_construction, construction_complete_at, demolish_at, stalled_resource
 `
 
 type CreateBuildingParams struct {
@@ -31,13 +50,23 @@ func (q *Queries) CreateBuilding(ctx context.Context, arg CreateBuildingParams)
 		&i.CreatedAt,
 		&i.UnderConstruction,
 		&i.ConstructionCompleteAt,
-		&i.LastProductionAt,
+		&i.DemolishAt,
+		&i.StalledResource,
 	)
 	return i, err
 }
 
+const deleteBuilding = `-- name: DeleteBuilding :exec
+DELETE FROM buildings WHERE id = $1
+`
+
+func (q *Queries) DeleteBuilding(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, deleteBuilding, id)
+	return err
+}
+
 const getBuilding = `-- name: GetBuilding :one
-SELECT id, port_id, type, level, created_at, under_construction, construction_complete_at, last_production_at FROM buildings WHERE id = $1
+SELECT id, port_id, type, level, created_at, under_construction, construction_complete_at, demolish_at, stalled_resource FROM buildings WHERE id = $1
 `
 
 func (q *Queries) GetBuilding(ctx context.Context, id int32) (Building, error) {
@@ -51,13 +80,14 @@ func (q *Queries) GetBuilding(ctx context.Context, id int32) (Building, error) {
 		&i.CreatedAt,
 		&i.UnderConstruction,
 		&i.ConstructionCompleteAt,
-		&i.LastProductionAt,
+		&i.DemolishAt,
+		&i.StalledResource,
 	)
 	return i, err
 }
 
 const getBuildingByPortAndType = `-- name: GetBuildingByPortAndType :one
-SELECT id, port_id, type, level, created_at, under_construction, construction_complete_at, last_production_at FROM buildings WHERE port_id = $1 AND type = $2
+SELECT id, port_id, type, level, created_at, under_construction, construction_complete_at, demolish_at, stalled_resource FROM buildings WHERE port_id = $1 AND type = $2
 `
 
 type GetBuildingByPortAndTypeParams struct {
@@ -76,13 +106,38 @@ func (q *Queries) GetBuildingByPortAndType(ctx context.Context, arg GetBuildingB
 		&i.CreatedAt,
 		&i.UnderConstruction,
 		&i.ConstructionCompleteAt,
-		&i.LastProductionAt,
+		&i.DemolishAt,
+		&i.StalledResource,
 	)
 	return i, err
 }
 
+const getBuildingInvestments = `-- name: GetBuildingInvestments :many
+SELECT building_id, resource_type, amount FROM building_investments WHERE building_id = $1 ORDER BY resource_type
+`
+
+func (q *Queries) GetBuildingInvestments(ctx context.Context, buildingID int32) ([]BuildingInvestment, error) {
+	rows, err := q.db.Query(ctx, getBuildingInvestments, buildingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BuildingInvestment
+	for rows.Next() {
+		var i BuildingInvestment
+		if err := rows.Scan(&i.BuildingID, &i.ResourceType, &i.Amount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getBuildingsByPort = `-- name: GetBuildingsByPort :many
-SELECT id, port_id, type, level, created_at, under_construction, construction_complete_at, last_production_at FROM buildings WHERE port_id = $1
+SELECT id, port_id, type, level, created_at, under_construction, construction_complete_at, demolish_at, stalled_resource FROM buildings WHERE port_id = $1
 `
 
 func (q *Queries) GetBuildingsByPort(ctx context.Context, portID int32) ([]Building, error) {
@@ -102,7 +157,8 @@ func (q *Queries) GetBuildingsByPort(ctx context.Context, portID int32) ([]Build
 			&i.CreatedAt,
 			&i.UnderConstruction,
 			&i.ConstructionCompleteAt,
-			&i.LastProductionAt,
+			&i.DemolishAt,
+			&i.StalledResource,
 		); err != nil {
 			return nil, err
 		}
@@ -114,11 +170,61 @@ func (q *Queries) GetBuildingsByPort(ctx context.Context, portID int32) ([]Build
 	return items, nil
 }
 
+const markBuildingDemolishing = `-- name: MarkBuildingDemolishing :exec
+UPDATE buildings
+SET demolish_at = $2
+WHERE id = $1
+`
+
+type MarkBuildingDemolishingParams struct {
+	ID         int32
+	DemolishAt pgtype.Timestamptz
+}
+
+func (q *Queries) MarkBuildingDemolishing(ctx context.Context, arg MarkBuildingDemolishingParams) error {
+	_, err := q.db.Exec(ctx, markBuildingDemolishing, arg.ID, arg.DemolishAt)
+	return err
+}
+
+const revertBuildingUpgrade = `-- name: RevertBuildingUpgrade :exec
+UPDATE buildings
+SET level = $2,
+    under_construction = FALSE,
+    construction_complete_at = NULL
+WHERE id = $1
+`
+
+type RevertBuildingUpgradeParams struct {
+	ID    int32
+	Level int32
+}
+
+func (q *Queries) RevertBuildingUpgrade(ctx context.Context, arg RevertBuildingUpgradeParams) error {
+	_, err := q.db.Exec(ctx, revertBuildingUpgrade, arg.ID, arg.Level)
+	return err
+}
+
+const setBuildingStalled = `-- name: SetBuildingStalled :exec
+UPDATE buildings
+SET stalled_resource = $2
+WHERE id = $1
+`
+
+type SetBuildingStalledParams struct {
+	ID              int32
+	StalledResource pgtype.Text
+}
+
+func (q *Queries) SetBuildingStalled(ctx context.Context, arg SetBuildingStalledParams) error {
+	_, err := q.db.Exec(ctx, setBuildingStalled, arg.ID, arg.StalledResource)
+	return err
+}
+
 const updateBuilding = `-- name: UpdateBuilding :one
 UPDATE buildings
 SET level = $2
 WHERE id = $1
-RETURNING id, port_id, type, level, created_at, under_construction, construction_complete_at, last_production_at
+RETURNING id, port_id, type, level, created_at, under_construction, construction_complete_at, demolish_at, stalled_resource
 `
 
 type UpdateBuildingParams struct {
@@ -137,7 +243,8 @@ func (q *Queries) UpdateBuilding(ctx context.Context, arg UpdateBuildingParams)
 		&i.CreatedAt,
 		&i.UnderConstruction,
 		&i.ConstructionCompleteAt,
-		&i.LastProductionAt,
+		&i.DemolishAt,
+		&i.StalledResource,
 	)
 	return i, err
 }
@@ -11,8 +11,24 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const addFactionTreasury = `-- name: AddFactionTreasury :exec
+UPDATE factions
+SET treasury = treasury + $2
+WHERE id = $1
+`
+
+type AddFactionTreasuryParams struct {
+	ID       int32
+	Treasury int64
+}
+
+func (q *Queries) AddFactionTreasury(ctx context.Context, arg AddFactionTreasuryParams) error {
+	_, err := q.db.Exec(ctx, addFactionTreasury, arg.ID, arg.Treasury)
+	return err
+}
+
 const getAllFactions = `-- name: GetAllFactions :many
-SELECT id, name FROM factions ORDER BY id
+SELECT id, name, buy_modifier, sell_modifier, tariff_rate, treasury FROM factions ORDER BY id
 `
 
 func (q *Queries) GetAllFactions(ctx context.Context) ([]Faction, error) {
@@ -24,7 +40,14 @@ func (q *Queries) GetAllFactions(ctx context.Context) ([]Faction, error) {
 	var items []Faction
 	for rows.Next() {
 		var i Faction
-		if err := rows.Scan(&i.ID, &i.Name); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.BuyModifier,
+			&i.SellModifier,
+			&i.TariffRate,
+			&i.Treasury,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -36,27 +59,89 @@ func (q *Queries) GetAllFactions(ctx context.Context) ([]Faction, error) {
 }
 
 const getFactionByID = `-- name: GetFactionByID :one
-SELECT id, name FROM factions WHERE id = $1
+SELECT id, name, buy_modifier, sell_modifier, tariff_rate, treasury FROM factions WHERE id = $1
 `
 
 func (q *Queries) GetFactionByID(ctx context.Context, id int32) (Faction, error) {
 	row := q.db.QueryRow(ctx, getFactionByID, id)
 	var i Faction
-	err := row.Scan(&i.ID, &i.Name)
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.BuyModifier,
+		&i.SellModifier,
+		&i.TariffRate,
+		&i.Treasury,
+	)
 	return i, err
 }
 
 const getFactionByName = `-- name: GetFactionByName :one
-SELECT id, name FROM factions WHERE name = $1
+SELECT id, name, buy_modifier, sell_modifier, tariff_rate, treasury FROM factions WHERE name = $1
 `
 
 func (q *Queries) GetFactionByName(ctx context.Context, name string) (Faction, error) {
 	row := q.db.QueryRow(ctx, getFactionByName, name)
 	var i Faction
-	err := row.Scan(&i.ID, &i.Name)
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.BuyModifier,
+		&i.SellModifier,
+		&i.TariffRate,
+		&i.Treasury,
+	)
 	return i, err
 }
 
+const getFactionRelations = `-- name: GetFactionRelations :many
+SELECT faction_id, other_faction_id, stance FROM faction_relations ORDER BY faction_id, other_faction_id
+`
+
+func (q *Queries) GetFactionRelations(ctx context.Context) ([]FactionRelation, error) {
+	rows, err := q.db.Query(ctx, getFactionRelations)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FactionRelation
+	for rows.Next() {
+		var i FactionRelation
+		if err := rows.Scan(&i.FactionID, &i.OtherFactionID, &i.Stance); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFactionStances = `-- name: GetFactionStances :many
+SELECT stance, rate_bonus, tariff_multiplier FROM faction_stances ORDER BY rate_bonus DESC
+`
+
+func (q *Queries) GetFactionStances(ctx context.Context) ([]FactionStance, error) {
+	rows, err := q.db.Query(ctx, getFactionStances)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FactionStance
+	for rows.Next() {
+		var i FactionStance
+		if err := rows.Scan(&i.Stance, &i.RateBonus, &i.TariffMultiplier); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPlayerWithFaction = `-- name: GetPlayerWithFaction :one
 SELECT 
     p.id,
@@ -96,6 +181,27 @@ func (q *Queries) GetPlayerWithFaction(ctx context.Context, id int32) (GetPlayer
 	return i, err
 }
 
+const getTradeStance = `-- name: GetTradeStance :one
+SELECT stance, rate_bonus, tariff_multiplier FROM faction_stances
+WHERE stance = COALESCE(
+    (SELECT fr.stance FROM faction_relations fr
+     WHERE fr.faction_id = $1 AND fr.other_faction_id = $2),
+    CASE WHEN $1 = $2 THEN 'own' ELSE 'neutral' END
+)
+`
+
+type GetTradeStanceParams struct {
+	PostFaction   int32
+	PlayerFaction int32
+}
+
+func (q *Queries) GetTradeStance(ctx context.Context, arg GetTradeStanceParams) (FactionStance, error) {
+	row := q.db.QueryRow(ctx, getTradeStance, arg.PostFaction, arg.PlayerFaction)
+	var i FactionStance
+	err := row.Scan(&i.Stance, &i.RateBonus, &i.TariffMultiplier)
+	return i, err
+}
+
 const updatePlayerFaction = `-- name: UpdatePlayerFaction :exec
 UPDATE players 
 SET faction = $1
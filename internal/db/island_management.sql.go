@@ -11,93 +11,75 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-const addResourcesToPort = `-- name: AddResourcesToPort :exec
-UPDATE resources 
-SET 
-    wood = wood + $2,
-    iron = iron + $3,
-    rum = rum + $4,
-    sugar = sugar + $5,
-    tobacco = tobacco + $6,
-    cotton = cotton + $7,
-    coffee = coffee + $8,
-    grain = grain + $9,
-    gold = gold + $10,
-    silver = silver + $11,
+const addPortResource = `-- name: AddPortResource :exec
+INSERT INTO port_resources (port_id, resource_type, amount)
+VALUES ($1, $2, $3)
+ON CONFLICT (port_id, resource_type) DO UPDATE
+SET amount = port_resources.amount + EXCLUDED.amount,
     updated_at = NOW()
-WHERE port_id = $1
 `
 
-type AddResourcesToPortParams struct {
-	PortID  int32
-	Wood    int32
-	Iron    int32
-	Rum     int32
-	Sugar   int32
-	Tobacco int32
-	Cotton  int32
-	Coffee  int32
-	Grain   int32
-	Gold    int32
-	Silver  int32
-}
-
-func (q *Queries) AddResourcesToPort(ctx context.Context, arg AddResourcesToPortParams) error {
-	_, err := q.db.Exec(ctx, addResourcesToPort,
-		arg.PortID,
-		arg.Wood,
-		arg.Iron,
-		arg.Rum,
-		arg.Sugar,
-		arg.Tobacco,
-		arg.Cotton,
-		arg.Coffee,
-		arg.Grain,
-		arg.Gold,
-		arg.Silver,
-	)
+type AddPortResourceParams struct {
+	PortID       int32
+	ResourceType string
+	Amount       int32
+}
+
+func (q *Queries) AddPortResource(ctx context.Context, arg AddPortResourceParams) error {
+	_, err := q.db.Exec(ctx, addPortResource, arg.PortID, arg.ResourceType, arg.Amount)
 	return err
 }
 
-const checkResourceAvailability = `-- name: CheckResourceAvailability :one
-SELECT 
-    (wood >= $2) as has_wood,
-    (iron >= $3) as has_iron,
-    (gold >= $4) as has_gold
-FROM resources 
-WHERE port_id = $1
+const calculatePortProductionRates = `-- name: CalculatePortProductionRates :many
+SELECT bp.resource_type,
+    SUM(CASE WHEN bp.flow = 'output' THEN bp.production_per_hour ELSE -bp.production_per_hour END)::integer AS rate_per_hour
+FROM buildings b
+JOIN building_types bt ON b.type = bt.type_name
+JOIN building_production bp ON bp.building_type_id = bt.id AND bp.level = b.level
+WHERE b.port_id = $1 AND b.under_construction = FALSE AND b.demolish_at IS NULL
+GROUP BY bp.resource_type
 `
 
-type CheckResourceAvailabilityParams struct {
-	PortID int32
-	Wood   int32
-	Iron   int32
-	Gold   int32
+type CalculatePortProductionRatesRow struct {
+	ResourceType string
+	RatePerHour  int32
 }
 
-type CheckResourceAvailabilityRow struct {
-	HasWood bool
-	HasIron bool
-	HasGold bool
+func (q *Queries) CalculatePortProductionRates(ctx context.Context, portID int32) ([]CalculatePortProductionRatesRow, error) {
+	rows, err := q.db.Query(ctx, calculatePortProductionRates, portID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CalculatePortProductionRatesRow
+	for rows.Next() {
+		var i CalculatePortProductionRatesRow
+		if err := rows.Scan(&i.ResourceType, &i.RatePerHour); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-func (q *Queries) CheckResourceAvailability(ctx context.Context, arg CheckResourceAvailabilityParams) (CheckResourceAvailabilityRow, error) {
-	row := q.db.QueryRow(ctx, checkResourceAvailability,
-		arg.PortID,
-		arg.Wood,
-		arg.Iron,
-		arg.Gold,
-	)
-	var i CheckResourceAvailabilityRow
-	err := row.Scan(&i.HasWood, &i.HasIron, &i.HasGold)
-	return i, err
+const clearPortProductionRates = `-- name: ClearPortProductionRates :exec
+UPDATE port_production
+SET rate_per_hour = 0
+WHERE port_id = $1
+`
+
+func (q *Queries) ClearPortProductionRates(ctx context.Context, portID int32) error {
+	_, err := q.db.Exec(ctx, clearPortProductionRates, portID)
+	return err
 }
 
 const completeBuildingConstruction = `-- name: CompleteBuildingConstruction :exec
 UPDATE buildings 
 SET under_construction = FALSE, 
-    construction_complete_at = NULL,
-    last_production_at = NOW()
+    construction_complete_at = NULL
 WHERE id = $1
 `
 
@@ -106,58 +88,31 @@ func (q *Queries) CompleteBuildingConstruction(ctx context.Context, id int32) er
 	return err
 }
 
-const consumeResourcesFromPort = `-- name: ConsumeResourcesFromPort :exec
-UPDATE resources 
-SET 
-    wood = GREATEST(0, wood - $2),
-    iron = GREATEST(0, iron - $3),
-    rum = GREATEST(0, rum - $4),
-    sugar = GREATEST(0, sugar - $5),
-    tobacco = GREATEST(0, tobacco - $6),
-    cotton = GREATEST(0, cotton - $7),
-    coffee = GREATEST(0, coffee - $8),
-    grain = GREATEST(0, grain - $9),
-    gold = GREATEST(0, gold - $10),
-    silver = GREATEST(0, silver - $11),
+const consumePortResource = `-- name: ConsumePortResource :execrows
+UPDATE port_resources
+SET amount = amount - $3,
     updated_at = NOW()
-WHERE port_id = $1
+WHERE port_id = $1 AND resource_type = $2 AND amount >= $3
 `
 
-type ConsumeResourcesFromPortParams struct {
-	PortID  int32
-	Wood    int32
-	Iron    int32
-	Rum     int32
-	Sugar   int32
-	Tobacco int32
-	Cotton  int32
-	Coffee  int32
-	Grain   int32
-	Gold    int32
-	Silver  int32
-}
-
-func (q *Queries) ConsumeResourcesFromPort(ctx context.Context, arg ConsumeResourcesFromPortParams) error {
-	_, err := q.db.Exec(ctx, consumeResourcesFromPort,
-		arg.PortID,
-		arg.Wood,
-		arg.Iron,
-		arg.Rum,
-		arg.Sugar,
-		arg.Tobacco,
-		arg.Cotton,
-		arg.Coffee,
-		arg.Grain,
-		arg.Gold,
-		arg.Silver,
-	)
-	return err
+type ConsumePortResourceParams struct {
+	PortID       int32
+	ResourceType string
+	Amount       int32
+}
+
+func (q *Queries) ConsumePortResource(ctx context.Context, arg ConsumePortResourceParams) (int64, error) {
+	result, err := q.db.Exec(ctx, consumePortResource, arg.PortID, arg.ResourceType, arg.Amount)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
 const createBuildingConstruction = `-- name: CreateBuildingConstruction :one
 INSERT INTO buildings (port_id, type, under_construction, construction_complete_at)
 VALUES ($1, $2, TRUE, $3)
-RETURNING id, port_id, type, level, created_at, under_construction, construction_complete_at, last_production_at
+RETURNING id, port_id, type, level, created_at, under_construction, construction_complete_at, demolish_at, stalled_resource
 `
 
 type CreateBuildingConstructionParams struct {
@@ -177,13 +132,14 @@ func (q *Queries) CreateBuildingConstruction(ctx context.Context, arg CreateBuil
 		&i.CreatedAt,
 		&i.UnderConstruction,
 		&i.ConstructionCompleteAt,
-		&i.LastProductionAt,
+		&i.DemolishAt,
+		&i.StalledResource,
 	)
 	return i, err
 }
 
 const getAllBuildingTypes = `-- name: GetAllBuildingTypes :many
-SELECT id, type_name, display_name, description, category, max_level, base_cost_wood, base_cost_iron, base_cost_gold, base_build_time, created_at FROM building_types ORDER BY category, type_name
+SELECT id, type_name, display_name, description, category, max_level, base_build_time, created_at, build_time_multiplier, build_time_exponent FROM building_types ORDER BY category, type_name
 `
 
 // Building Types Queries
@@ -203,11 +159,10 @@ func (q *Queries) GetAllBuildingTypes(ctx context.Context) ([]BuildingType, erro
 			&i.Description,
 			&i.Category,
 			&i.MaxLevel,
-			&i.BaseCostWood,
-			&i.BaseCostIron,
-			&i.BaseCostGold,
 			&i.BaseBuildTime,
 			&i.CreatedAt,
+			&i.BuildTimeMultiplier,
+			&i.BuildTimeExponent,
 		); err != nil {
 			return nil, err
 		}
@@ -220,17 +175,18 @@ func (q *Queries) GetAllBuildingTypes(ctx context.Context) ([]BuildingType, erro
 }
 
 const getAllProductionForBuildingType = `-- name: GetAllProductionForBuildingType :many
-SELECT bp.level, bp.resource_type, bp.production_rate
+SELECT bp.level, bp.resource_type, bp.flow, bp.production_per_hour
 FROM building_production bp
 JOIN building_types bt ON bp.building_type_id = bt.id
 WHERE bt.type_name = $1
-ORDER BY bp.level, bp.resource_type
+ORDER BY bp.level, bp.flow, bp.resource_type
 `
 
 type GetAllProductionForBuildingTypeRow struct {
-	Level          int32
-	ResourceType   string
-	ProductionRate int32
+	Level             int32
+	ResourceType      string
+	Flow              string
+	ProductionPerHour int32
 }
 
 func (q *Queries) GetAllProductionForBuildingType(ctx context.Context, typeName string) ([]GetAllProductionForBuildingTypeRow, error) {
@@ -242,7 +198,44 @@ func (q *Queries) GetAllProductionForBuildingType(ctx context.Context, typeName
 	var items []GetAllProductionForBuildingTypeRow
 	for rows.Next() {
 		var i GetAllProductionForBuildingTypeRow
-		if err := rows.Scan(&i.Level, &i.ResourceType, &i.ProductionRate); err != nil {
+		if err := rows.Scan(
+			&i.Level,
+			&i.ResourceType,
+			&i.Flow,
+			&i.ProductionPerHour,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllResourceTypes = `-- name: GetAllResourceTypes :many
+SELECT name, display_name, description, base_capacity, capacity_per_warehouse_level, sort_order, created_at FROM resource_types ORDER BY sort_order, name
+`
+
+func (q *Queries) GetAllResourceTypes(ctx context.Context) ([]ResourceType, error) {
+	rows, err := q.db.Query(ctx, getAllResourceTypes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ResourceType
+	for rows.Next() {
+		var i ResourceType
+		if err := rows.Scan(
+			&i.Name,
+			&i.DisplayName,
+			&i.Description,
+			&i.BaseCapacity,
+			&i.CapacityPerWarehouseLevel,
+			&i.SortOrder,
+			&i.CreatedAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -254,7 +247,7 @@ func (q *Queries) GetAllProductionForBuildingType(ctx context.Context, typeName
 }
 
 const getBuildingTypeByName = `-- name: GetBuildingTypeByName :one
-SELECT id, type_name, display_name, description, category, max_level, base_cost_wood, base_cost_iron, base_cost_gold, base_build_time, created_at FROM building_types WHERE type_name = $1
+SELECT id, type_name, display_name, description, category, max_level, base_build_time, created_at, build_time_multiplier, build_time_exponent FROM building_types WHERE type_name = $1
 `
 
 func (q *Queries) GetBuildingTypeByName(ctx context.Context, typeName string) (BuildingType, error) {
@@ -267,17 +260,16 @@ func (q *Queries) GetBuildingTypeByName(ctx context.Context, typeName string) (B
 		&i.Description,
 		&i.Category,
 		&i.MaxLevel,
-		&i.BaseCostWood,
-		&i.BaseCostIron,
-		&i.BaseCostGold,
 		&i.BaseBuildTime,
 		&i.CreatedAt,
+		&i.BuildTimeMultiplier,
+		&i.BuildTimeExponent,
 	)
 	return i, err
 }
 
 const getBuildingTypesByCategory = `-- name: GetBuildingTypesByCategory :many
-SELECT id, type_name, display_name, description, category, max_level, base_cost_wood, base_cost_iron, base_cost_gold, base_build_time, created_at FROM building_types WHERE category = $1 ORDER BY type_name
+SELECT id, type_name, display_name, description, category, max_level, base_build_time, created_at, build_time_multiplier, build_time_exponent FROM building_types WHERE category = $1 ORDER BY type_name
 `
 
 func (q *Queries) GetBuildingTypesByCategory(ctx context.Context, category string) ([]BuildingType, error) {
@@ -296,11 +288,10 @@ func (q *Queries) GetBuildingTypesByCategory(ctx context.Context, category strin
 			&i.Description,
 			&i.Category,
 			&i.MaxLevel,
-			&i.BaseCostWood,
-			&i.BaseCostIron,
-			&i.BaseCostGold,
 			&i.BaseBuildTime,
 			&i.CreatedAt,
+			&i.BuildTimeMultiplier,
+			&i.BuildTimeExponent,
 		); err != nil {
 			return nil, err
 		}
@@ -312,93 +303,38 @@ func (q *Queries) GetBuildingTypesByCategory(ctx context.Context, category strin
 	return items, nil
 }
 
-const getBuildingsReadyForProduction = `-- name: GetBuildingsReadyForProduction :many
-SELECT 
-    b.id,
-    b.port_id,
-    b.type,
-    b.level,
-    b.last_production_at
-FROM buildings b
-WHERE b.under_construction = FALSE 
-AND (b.last_production_at IS NULL OR b.last_production_at < $1)
-`
-
-type GetBuildingsReadyForProductionRow struct {
-	ID               int32
-	PortID           int32
-	Type             string
-	Level            int32
-	LastProductionAt pgtype.Timestamptz
-}
-
-func (q *Queries) GetBuildingsReadyForProduction(ctx context.Context, lastProductionAt pgtype.Timestamptz) ([]GetBuildingsReadyForProductionRow, error) {
-	rows, err := q.db.Query(ctx, getBuildingsReadyForProduction, lastProductionAt)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetBuildingsReadyForProductionRow
-	for rows.Next() {
-		var i GetBuildingsReadyForProductionRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.PortID,
-			&i.Type,
-			&i.Level,
-			&i.LastProductionAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
-const getBuildingsUnderConstruction = `-- name: GetBuildingsUnderConstruction :many
-SELECT b.id, b.port_id, b.type, b.level, b.created_at, b.under_construction, b.construction_complete_at, b.last_production_at, bt.display_name, bt.base_build_time
+const getPortBuildingFlows = `-- name: GetPortBuildingFlows :many
+SELECT b.id AS building_id, b.stalled_resource, bp.resource_type, bp.flow, bp.production_per_hour
 FROM buildings b
 JOIN building_types bt ON b.type = bt.type_name
-WHERE b.under_construction = TRUE 
-AND b.construction_complete_at <= NOW()
+JOIN building_production bp ON bp.building_type_id = bt.id AND bp.level = b.level
+WHERE b.port_id = $1 AND b.under_construction = FALSE AND b.demolish_at IS NULL
+ORDER BY b.id, bp.flow, bp.resource_type
 `
 
-type GetBuildingsUnderConstructionRow struct {
-	ID                     int32
-	PortID                 int32
-	Type                   string
-	Level                  int32
-	CreatedAt              pgtype.Timestamptz
-	UnderConstruction      bool
-	ConstructionCompleteAt pgtype.Timestamptz
-	LastProductionAt       pgtype.Timestamptz
-	DisplayName            string
-	BaseBuildTime          int32
+type GetPortBuildingFlowsRow struct {
+	BuildingID        int32
+	StalledResource   pgtype.Text
+	ResourceType      string
+	Flow              string
+	ProductionPerHour int32
 }
 
-func (q *Queries) GetBuildingsUnderConstruction(ctx context.Context) ([]GetBuildingsUnderConstructionRow, error) {
-	rows, err := q.db.Query(ctx, getBuildingsUnderConstruction)
+func (q *Queries) GetPortBuildingFlows(ctx context.Context, portID int32) ([]GetPortBuildingFlowsRow, error) {
+	rows, err := q.db.Query(ctx, getPortBuildingFlows, portID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetBuildingsUnderConstructionRow
+	var items []GetPortBuildingFlowsRow
 	for rows.Next() {
-		var i GetBuildingsUnderConstructionRow
+		var i GetPortBuildingFlowsRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.PortID,
-			&i.Type,
-			&i.Level,
-			&i.CreatedAt,
-			&i.UnderConstruction,
-			&i.ConstructionCompleteAt,
-			&i.LastProductionAt,
-			&i.DisplayName,
-			&i.BaseBuildTime,
+			&i.BuildingID,
+			&i.StalledResource,
+			&i.ResourceType,
+			&i.Flow,
+			&i.ProductionPerHour,
 		); err != nil {
 			return nil, err
 		}
@@ -418,7 +354,8 @@ SELECT
     b.level,
     b.under_construction,
     b.construction_complete_at,
-    b.last_production_at,
+    b.demolish_at,
+    b.stalled_resource,
     b.created_at,
     bt.display_name,
     bt.description,
@@ -437,7 +374,8 @@ type GetPortBuildingsRow struct {
 	Level                  int32
 	UnderConstruction      bool
 	ConstructionCompleteAt pgtype.Timestamptz
-	LastProductionAt       pgtype.Timestamptz
+	DemolishAt             pgtype.Timestamptz
+	StalledResource        pgtype.Text
 	CreatedAt              pgtype.Timestamptz
 	DisplayName            string
 	Description            pgtype.Text
@@ -461,7 +399,8 @@ func (q *Queries) GetPortBuildings(ctx context.Context, portID int32) ([]GetPort
 			&i.Level,
 			&i.UnderConstruction,
 			&i.ConstructionCompleteAt,
-			&i.LastProductionAt,
+			&i.DemolishAt,
+			&i.StalledResource,
 			&i.CreatedAt,
 			&i.DisplayName,
 			&i.Description,
@@ -478,6 +417,104 @@ func (q *Queries) GetPortBuildings(ctx context.Context, portID int32) ([]GetPort
 	return items, nil
 }
 
+const getPortProduction = `-- name: GetPortProduction :many
+SELECT port_id, resource_type, rate_per_hour, carry FROM port_production WHERE port_id = $1 ORDER BY resource_type
+`
+
+// Port Production Queries
+func (q *Queries) GetPortProduction(ctx context.Context, portID int32) ([]PortProduction, error) {
+	rows, err := q.db.Query(ctx, getPortProduction, portID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PortProduction
+	for rows.Next() {
+		var i PortProduction
+		if err := rows.Scan(
+			&i.PortID,
+			&i.ResourceType,
+			&i.RatePerHour,
+			&i.Carry,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPortResources = `-- name: GetPortResources :many
+SELECT port_id, resource_type, amount, updated_at FROM port_resources
+WHERE port_id = $1
+ORDER BY resource_type
+`
+
+func (q *Queries) GetPortResources(ctx context.Context, portID int32) ([]PortResource, error) {
+	rows, err := q.db.Query(ctx, getPortResources, portID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PortResource
+	for rows.Next() {
+		var i PortResource
+		if err := rows.Scan(
+			&i.PortID,
+			&i.ResourceType,
+			&i.Amount,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPortStorageCapacity = `-- name: GetPortStorageCapacity :many
+SELECT 
+    rt.name AS resource_type,
+    (rt.base_capacity + rt.capacity_per_warehouse_level * COALESCE((
+        SELECT SUM(CASE WHEN b.under_construction THEN b.level - 1 ELSE b.level END)
+        FROM buildings b
+        WHERE b.port_id = $1 AND b.type = 'warehouse'
+    ), 0))::integer AS capacity
+FROM resource_types rt
+ORDER BY rt.sort_order, rt.name
+`
+
+type GetPortStorageCapacityRow struct {
+	ResourceType string
+	Capacity     int32
+}
+
+func (q *Queries) GetPortStorageCapacity(ctx context.Context, portID int32) ([]GetPortStorageCapacityRow, error) {
+	rows, err := q.db.Query(ctx, getPortStorageCapacity, portID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPortStorageCapacityRow
+	for rows.Next() {
+		var i GetPortStorageCapacityRow
+		if err := rows.Scan(&i.ResourceType, &i.Capacity); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPortWithResources = `-- name: GetPortWithResources :one
 SELECT 
     p.id as port_id,
@@ -486,17 +523,9 @@ SELECT
     p.x,
     p.y,
     p.created_at as port_created_at,
-    r.wood,
-    r.iron,
-    r.rum,
-    r.sugar,
-    r.tobacco,
-    r.cotton,
-    r.coffee,
-    r.grain,
-    r.gold,
-    r.silver,
-    r.updated_at as resources_updated_at
+    p.protected_until,
+    r.updated_at as resources_updated_at,
+    r.settled_at as resources_settled_at
 FROM ports p
 LEFT JOIN resources r ON p.id = r.port_id
 WHERE p.id = $1
@@ -509,20 +538,11 @@ type GetPortWithResourcesRow struct {
 	X                  int32
 	Y                  int32
 	PortCreatedAt      pgtype.Timestamptz
-	Wood               pgtype.Int4
-	Iron               pgtype.Int4
-	Rum                pgtype.Int4
-	Sugar              pgtype.Int4
-	Tobacco            pgtype.Int4
-	Cotton             pgtype.Int4
-	Coffee             pgtype.Int4
-	Grain              pgtype.Int4
-	Gold               pgtype.Int4
-	Silver             pgtype.Int4
+	ProtectedUntil     pgtype.Timestamptz
 	ResourcesUpdatedAt pgtype.Timestamptz
+	ResourcesSettledAt pgtype.Timestamptz
 }
 
-// Island/Port Management Queries
 func (q *Queries) GetPortWithResources(ctx context.Context, id int32) (GetPortWithResourcesRow, error) {
 	row := q.db.QueryRow(ctx, getPortWithResources, id)
 	var i GetPortWithResourcesRow
@@ -533,23 +553,15 @@ func (q *Queries) GetPortWithResources(ctx context.Context, id int32) (GetPortWi
 		&i.X,
 		&i.Y,
 		&i.PortCreatedAt,
-		&i.Wood,
-		&i.Iron,
-		&i.Rum,
-		&i.Sugar,
-		&i.Tobacco,
-		&i.Cotton,
-		&i.Coffee,
-		&i.Grain,
-		&i.Gold,
-		&i.Silver,
+		&i.ProtectedUntil,
 		&i.ResourcesUpdatedAt,
+		&i.ResourcesSettledAt,
 	)
 	return i, err
 }
 
 const getProductionRatesForBuilding = `-- name: GetProductionRatesForBuilding :many
-SELECT bp.resource_type, bp.production_rate 
+SELECT bp.resource_type, bp.flow, bp.production_per_hour 
 FROM building_production bp
 JOIN building_types bt ON bp.building_type_id = bt.id
 WHERE bt.type_name = $1 AND bp.level = $2
@@ -561,8 +573,9 @@ type GetProductionRatesForBuildingParams struct {
 }
 
 type GetProductionRatesForBuildingRow struct {
-	ResourceType   string
-	ProductionRate int32
+	ResourceType      string
+	Flow              string
+	ProductionPerHour int32
 }
 
 // Building Production Queries
@@ -575,7 +588,7 @@ func (q *Queries) GetProductionRatesForBuilding(ctx context.Context, arg GetProd
 	var items []GetProductionRatesForBuildingRow
 	for rows.Next() {
 		var i GetProductionRatesForBuildingRow
-		if err := rows.Scan(&i.ResourceType, &i.ProductionRate); err != nil {
+		if err := rows.Scan(&i.ResourceType, &i.Flow, &i.ProductionPerHour); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -598,14 +611,52 @@ func (q *Queries) InitializePortResources(ctx context.Context, portID int32) err
 	return err
 }
 
-const updateBuildingLastProduction = `-- name: UpdateBuildingLastProduction :exec
-UPDATE buildings 
-SET last_production_at = NOW()
-WHERE id = $1
+const lockPortResources = `-- name: LockPortResources :one
+SELECT port_id, created_at, updated_at, settled_at FROM resources WHERE port_id = $1 FOR UPDATE
 `
 
-func (q *Queries) UpdateBuildingLastProduction(ctx context.Context, id int32) error {
-	_, err := q.db.Exec(ctx, updateBuildingLastProduction, id)
+func (q *Queries) LockPortResources(ctx context.Context, portID int32) (Resource, error) {
+	row := q.db.QueryRow(ctx, lockPortResources, portID)
+	var i Resource
+	err := row.Scan(
+		&i.PortID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SettledAt,
+	)
+	return i, err
+}
+
+const markResourcesSettled = `-- name: MarkResourcesSettled :exec
+UPDATE resources
+SET settled_at = $2
+WHERE port_id = $1
+`
+
+type MarkResourcesSettledParams struct {
+	PortID    int32
+	SettledAt pgtype.Timestamptz
+}
+
+func (q *Queries) MarkResourcesSettled(ctx context.Context, arg MarkResourcesSettledParams) error {
+	_, err := q.db.Exec(ctx, markResourcesSettled, arg.PortID, arg.SettledAt)
+	return err
+}
+
+const updatePortProductionCarry = `-- name: UpdatePortProductionCarry :exec
+UPDATE port_production
+SET carry = $3
+WHERE port_id = $1 AND resource_type = $2
+`
+
+type UpdatePortProductionCarryParams struct {
+	PortID       int32
+	ResourceType string
+	Carry        float64
+}
+
+func (q *Queries) UpdatePortProductionCarry(ctx context.Context, arg UpdatePortProductionCarryParams) error {
+	_, err := q.db.Exec(ctx, updatePortProductionCarry, arg.PortID, arg.ResourceType, arg.Carry)
 	return err
 }
 
@@ -630,3 +681,20 @@ func (q *Queries) UpgradeBuilding(ctx context.Context, arg UpgradeBuildingParams
 	_, err := q.db.Exec(ctx, upgradeBuilding, arg.ID, arg.ConstructionCompleteAt)
 	return err
 }
+
+const upsertPortProductionRate = `-- name: UpsertPortProductionRate :exec
+INSERT INTO port_production (port_id, resource_type, rate_per_hour)
+VALUES ($1, $2, $3)
+ON CONFLICT (port_id, resource_type) DO UPDATE SET rate_per_hour = EXCLUDED.rate_per_hour
+`
+
+type UpsertPortProductionRateParams struct {
+	PortID       int32
+	ResourceType string
+	RatePerHour  int32
+}
+
+func (q *Queries) UpsertPortProductionRate(ctx context.Context, arg UpsertPortProductionRateParams) error {
+	_, err := q.db.Exec(ctx, upsertPortProductionRate, arg.PortID, arg.ResourceType, arg.RatePerHour)
+	return err
+}
@@ -8,6 +8,21 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type BattleReport struct {
+	ID               int32
+	EngagementID     string
+	AttackerPlayerID int32
+	DefenderPlayerID int32
+	AttackerFleetID  pgtype.Int4
+	DefenderFleetID  pgtype.Int4
+	PortID           pgtype.Int4
+	X                float64
+	Y                float64
+	Outcome          string
+	Report           []byte
+	FoughtAt         pgtype.Timestamptz
+}
+
 type Building struct {
 	ID                     int32
 	PortID                 int32
@@ -16,34 +31,190 @@ type Building struct {
 	CreatedAt              pgtype.Timestamptz
 	UnderConstruction      bool
 	ConstructionCompleteAt pgtype.Timestamptz
-	LastProductionAt       pgtype.Timestamptz
+	DemolishAt             pgtype.Timestamptz
+	StalledResource        pgtype.Text
+}
+
+type BuildingCostCurve struct {
+	BuildingType string
+	ResourceType string
+	BaseAmount   int32
+	Multiplier   float64
+	Exponent     float64
+}
+
+type BuildingEffect struct {
+	ID           int32
+	BuildingType string
+	Level        int32
+	Effect       string
+	Value        float64
+}
+
+type BuildingInvestment struct {
+	BuildingID   int32
+	ResourceType string
+	Amount       int32
+}
+
+type BuildingLevelCost struct {
+	BuildingType string
+	Level        int32
+	ResourceType string
+	Amount       int32
+}
+
+type BuildingLevelTime struct {
+	BuildingType    string
+	Level           int32
+	DurationSeconds int32
+}
+
+type BuildingPrerequisite struct {
+	ID               int32
+	BuildingType     string
+	Level            int32
+	RequiredBuilding pgtype.Text
+	RequiredLevel    pgtype.Int4
+	RequiredResearch pgtype.Text
 }
 
 type BuildingProduction struct {
-	ID             int32
-	BuildingTypeID int32
-	Level          int32
-	ResourceType   string
-	ProductionRate int32
+	ID                int32
+	BuildingTypeID    int32
+	Level             int32
+	ResourceType      string
+	ProductionPerHour int32
+	Flow              string
 }
 
 type BuildingType struct {
-	ID            int32
-	TypeName      string
-	DisplayName   string
-	Description   pgtype.Text
-	Category      string
-	MaxLevel      int32
-	BaseCostWood  int32
-	BaseCostIron  int32
-	BaseCostGold  int32
-	BaseBuildTime int32
-	CreatedAt     pgtype.Timestamptz
+	ID                  int32
+	TypeName            string
+	DisplayName         string
+	Description         pgtype.Text
+	Category            string
+	MaxLevel            int32
+	BaseBuildTime       int32
+	CreatedAt           pgtype.Timestamptz
+	BuildTimeMultiplier float64
+	BuildTimeExponent   float64
+}
+
+type ConstructionQueue struct {
+	ID              int32
+	PortID          int32
+	BuildingID      pgtype.Int4
+	BuildingType    string
+	TargetLevel     int32
+	DurationSeconds int32
+	Status          string
+	Position        int32
+	StartedAt       pgtype.Timestamptz
+	FinishesAt      pgtype.Timestamptz
+	CreatedAt       pgtype.Timestamptz
+}
+
+type ConstructionQueueCost struct {
+	QueueItemID  int32
+	ResourceType string
+	Amount       int32
 }
 
 type Faction struct {
-	ID   int32
-	Name string
+	ID           int32
+	Name         string
+	BuyModifier  float64
+	SellModifier float64
+	TariffRate   float64
+	Treasury     int64
+}
+
+type FactionRelation struct {
+	FactionID      int32
+	OtherFactionID int32
+	Stance         string
+}
+
+type FactionStance struct {
+	Stance           string
+	RateBonus        float64
+	TariffMultiplier float64
+}
+
+type Fleet struct {
+	ID           int32
+	PlayerID     int32
+	Name         string
+	Status       string
+	PortID       pgtype.Int4
+	X            float64
+	Y            float64
+	OriginX      pgtype.Float8
+	OriginY      pgtype.Float8
+	TargetX      pgtype.Float8
+	TargetY      pgtype.Float8
+	TargetPortID pgtype.Int4
+	DepartedAt   pgtype.Timestamptz
+	ArrivesAt    pgtype.Timestamptz
+	CreatedAt    pgtype.Timestamptz
+	Raiding      bool
+}
+
+type FleetCargo struct {
+	FleetID      int32
+	ResourceType string
+	Amount       int32
+}
+
+type MarketOrder struct {
+	ID           int32
+	PlayerID     int32
+	PortID       int32
+	Side         string
+	ResourceType string
+	PricePerUnit int32
+	Quantity     int32
+	Remaining    int32
+	Status       string
+	ExpiresAt    pgtype.Timestamptz
+	CreatedAt    pgtype.Timestamptz
+	ClosedAt     pgtype.Timestamptz
+}
+
+type MarketTrade struct {
+	ID           int32
+	ResourceType string
+	BuyOrderID   int32
+	SellOrderID  int32
+	Quantity     int32
+	PricePerUnit int32
+	CreatedAt    pgtype.Timestamptz
+}
+
+type NpcMarketPrice struct {
+	ResourceType  string
+	BaselinePrice float64
+	Price         float64
+	Depth         int32
+	PendingVolume int32
+	UpdatedAt     pgtype.Timestamptz
+}
+
+type NpcPriceHistory struct {
+	ID           int32
+	ResourceType string
+	Price        float64
+	Volume       int32
+	RecordedAt   pgtype.Timestamptz
+}
+
+type NpcTradingPost struct {
+	ID        int32
+	Name      string
+	FactionID int32
+	X         int32
+	Y         int32
 }
 
 type Player struct {
@@ -55,6 +226,12 @@ type Player struct {
 	UserID      pgtype.Int4
 }
 
+type PlayerResearch struct {
+	PlayerID     int32
+	ResearchType string
+	CompletedAt  pgtype.Timestamptz
+}
+
 type Port struct {
 	ID                           int32
 	PlayerID                     int32
@@ -64,22 +241,105 @@ type Port struct {
 	CreatedAt                    pgtype.Timestamptz
 	IslandType                   pgtype.Text
 	StartingResourcesInitialized pgtype.Bool
+	ProtectedUntil               pgtype.Timestamptz
+}
+
+type PortProduction struct {
+	PortID       int32
+	ResourceType string
+	RatePerHour  int32
+	Carry        float64
+}
+
+type PortResource struct {
+	PortID       int32
+	ResourceType string
+	Amount       int32
+	UpdatedAt    pgtype.Timestamptz
+}
+
+type RaidReport struct {
+	ID               int32
+	RaidID           string
+	AttackerPlayerID int32
+	DefenderPlayerID int32
+	FleetID          pgtype.Int4
+	PortID           int32
+	DefenseRating    int32
+	Outcome          string
+	Report           []byte
+	RaidedAt         pgtype.Timestamptz
+	Looted           int32
+}
+
+type ResearchType struct {
+	ID          int32
+	Name        string
+	DisplayName string
+	Description pgtype.Text
+	CreatedAt   pgtype.Timestamptz
 }
 
 type Resource struct {
 	PortID    int32
-	Wood      int32
-	Iron      int32
-	Rum       int32
-	Sugar     int32
-	Tobacco   int32
-	Cotton    int32
-	Coffee    int32
-	Grain     int32
-	Gold      int32
-	Silver    int32
 	CreatedAt pgtype.Timestamptz
 	UpdatedAt pgtype.Timestamptz
+	SettledAt pgtype.Timestamptz
+}
+
+type ResourceLedger struct {
+	ID           int64
+	PortID       int32
+	ResourceType string
+	Delta        int32
+	Reason       string
+	ReferenceID  pgtype.Text
+	CreatedAt    pgtype.Timestamptz
+}
+
+type ResourceType struct {
+	Name                      string
+	DisplayName               string
+	Description               pgtype.Text
+	BaseCapacity              int32
+	CapacityPerWarehouseLevel int32
+	SortOrder                 int32
+	CreatedAt                 pgtype.Timestamptz
+}
+
+type Ship struct {
+	ID          int32
+	PlayerID    int32
+	PortID      pgtype.Int4
+	ShipClass   string
+	Name        string
+	Status      string
+	Hull        int32
+	CompletesAt pgtype.Timestamptz
+	CreatedAt   pgtype.Timestamptz
+	FleetID     pgtype.Int4
+	Crew        int32
+}
+
+type ShipClass struct {
+	Name                  string
+	DisplayName           string
+	Description           pgtype.Text
+	RequiredShipyardLevel int32
+	BuildTimeSeconds      int32
+	CargoCapacity         int32
+	Speed                 float64
+	Hull                  int32
+	Cannons               int32
+	Crew                  int32
+	SortOrder             int32
+	CreatedAt             pgtype.Timestamptz
+}
+
+type ShipClassCost struct {
+	ShipClass    string
+	ResourceType string
+	Amount       int32
 }
 
 type User struct {
@@ -91,4 +351,5 @@ type User struct {
 	EmailVerificationExpiresAt pgtype.Timestamptz
 	CreatedAt                  pgtype.Timestamptz
 	UpdatedAt                  pgtype.Timestamptz
+	IsAdmin                    bool
 }
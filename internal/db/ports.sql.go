@@ -12,7 +12,7 @@ import (
 const createPlayerIsland = `-- name: CreatePlayerIsland :one
 INSERT INTO ports (player_id, name, x, y, island_type, starting_resources_initialized)
 VALUES ($1, $2, $3, $4, 'tropical', TRUE)
-RETURNING id, player_id, name, x, y, created_at, island_type, starting_resources_initialized
+RETURNING id, player_id, name, x, y, created_at, island_type, starting_resources_initialized, protected_until
 `
 
 type CreatePlayerIslandParams struct {
@@ -39,6 +39,7 @@ func (q *Queries) CreatePlayerIsland(ctx context.Context, arg CreatePlayerIsland
 		&i.CreatedAt,
 		&i.IslandType,
 		&i.StartingResourcesInitialized,
+		&i.ProtectedUntil,
 	)
 	return i, err
 }
@@ -46,7 +47,7 @@ func (q *Queries) CreatePlayerIsland(ctx context.Context, arg CreatePlayerIsland
 const createPort = `-- name: CreatePort :one
 INSERT INTO ports (player_id, name, x, y)
 VALUES ($1, $2, $3, $4)
-RETURNING id, player_id, name, x, y, created_at, island_type, starting_resources_initialized
+RETURNING id, player_id, name, x, y, created_at, island_type, starting_resources_initialized, protected_until
 `
 
 type CreatePortParams struct {
@@ -73,12 +74,23 @@ func (q *Queries) CreatePort(ctx context.Context, arg CreatePortParams) (Port, e
 		&i.CreatedAt,
 		&i.IslandType,
 		&i.StartingResourcesInitialized,
+		&i.ProtectedUntil,
 	)
 	return i, err
 }
 
+const endPortProtection = `-- name: EndPortProtection :exec
+UPDATE ports SET protected_until = NOW()
+WHERE player_id = $1 AND protected_until > NOW()
+`
+
+func (q *Queries) EndPortProtection(ctx context.Context, playerID int32) error {
+	_, err := q.db.Exec(ctx, endPortProtection, playerID)
+	return err
+}
+
 const getPortById = `-- name: GetPortById :one
-SELECT id, player_id, name, x, y, created_at, island_type, starting_resources_initialized FROM ports WHERE id = $1
+SELECT id, player_id, name, x, y, created_at, island_type, starting_resources_initialized, protected_until FROM ports WHERE id = $1
 `
 
 func (q *Queries) GetPortById(ctx context.Context, id int32) (Port, error) {
@@ -93,12 +105,13 @@ func (q *Queries) GetPortById(ctx context.Context, id int32) (Port, error) {
 		&i.CreatedAt,
 		&i.IslandType,
 		&i.StartingResourcesInitialized,
+		&i.ProtectedUntil,
 	)
 	return i, err
 }
 
 const getPortByPlayerId = `-- name: GetPortByPlayerId :one
-SELECT id, player_id, name, x, y, created_at, island_type, starting_resources_initialized FROM ports WHERE player_id = $1
+SELECT id, player_id, name, x, y, created_at, island_type, starting_resources_initialized, protected_until FROM ports WHERE player_id = $1
 `
 
 func (q *Queries) GetPortByPlayerId(ctx context.Context, playerID int32) (Port, error) {
@@ -113,6 +126,7 @@ func (q *Queries) GetPortByPlayerId(ctx context.Context, playerID int32) (Port,
 		&i.CreatedAt,
 		&i.IslandType,
 		&i.StartingResourcesInitialized,
+		&i.ProtectedUntil,
 	)
 	return i, err
 }
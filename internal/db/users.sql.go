@@ -14,7 +14,7 @@ import (
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (email, password_hash, email_verification_token, email_verification_expires_at)
 VALUES ($1, $2, $3, $4)
-RETURNING id, email, password_hash, email_verified, email_verification_token, email_verification_expires_at, created_at, updated_at
+RETURNING id, email, password_hash, email_verified, email_verification_token, email_verification_expires_at, created_at, updated_at, is_admin
 `
 
 type CreateUserParams struct {
@@ -41,12 +41,13 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.EmailVerificationExpiresAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsAdmin,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, password_hash, email_verified, email_verification_token, email_verification_expires_at, created_at, updated_at FROM users WHERE email = $1
+SELECT id, email, password_hash, email_verified, email_verification_token, email_verification_expires_at, created_at, updated_at, is_admin FROM users WHERE email = $1
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
@@ -61,12 +62,13 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.EmailVerificationExpiresAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsAdmin,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, email, password_hash, email_verified, email_verification_token, email_verification_expires_at, created_at, updated_at FROM users WHERE id = $1
+SELECT id, email, password_hash, email_verified, email_verification_token, email_verification_expires_at, created_at, updated_at, is_admin FROM users WHERE id = $1
 `
 
 func (q *Queries) GetUserByID(ctx context.Context, id int32) (User, error) {
@@ -81,12 +83,13 @@ func (q *Queries) GetUserByID(ctx context.Context, id int32) (User, error) {
 		&i.EmailVerificationExpiresAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsAdmin,
 	)
 	return i, err
 }
 
 const getUserByVerificationToken = `-- name: GetUserByVerificationToken :one
-SELECT id, email, password_hash, email_verified, email_verification_token, email_verification_expires_at, created_at, updated_at FROM users WHERE email_verification_token = $1
+SELECT id, email, password_hash, email_verified, email_verification_token, email_verification_expires_at, created_at, updated_at, is_admin FROM users WHERE email_verification_token = $1
 `
 
 func (q *Queries) GetUserByVerificationToken(ctx context.Context, emailVerificationToken pgtype.Text) (User, error) {
@@ -101,6 +104,7 @@ func (q *Queries) GetUserByVerificationToken(ctx context.Context, emailVerificat
 		&i.EmailVerificationExpiresAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsAdmin,
 	)
 	return i, err
 }
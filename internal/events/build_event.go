@@ -2,25 +2,49 @@ package events
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/bradcypert/stserver/internal/db"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func HandleBuildEvent(context context.Context, event GameEvent, pool *pgxpool.Pool) {
+type PortBuildingPayload struct {
+	PortID       int32  `json:"port_id"`
+	BuildingType string `json:"building_type"`
+}
+
+func RegisterBuildHandlers(registry *Registry, pool *pgxpool.Pool) {
+	Register(registry, GameEventPortBuilding, func(ctx context.Context, payload PortBuildingPayload) error {
+		return HandleBuildEvent(ctx, payload, pool)
+	})
+}
+
+func HandleBuildEvent(ctx context.Context, payload PortBuildingPayload, pool *pgxpool.Pool) error {
+	if payload.BuildingType == "" {
+		return fmt.Errorf("build event for port %d has no building type", payload.PortID)
+	}
+
 	d := db.New(pool)
-	building, err := d.GetBuildingByPortAndType(context, db.GetBuildingByPortAndTypeParams{
-		PortID: event.PortID,
-		Type:   event.BuildingType,
+	_, err := d.GetBuildingByPortAndType(ctx, db.GetBuildingByPortAndTypeParams{
+		PortID: payload.PortID,
+		Type:   payload.BuildingType,
 	})
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to get building: %w", err)
+	}
 
-	if (building == db.Building{}) {
-		building, err = d.CreateBuilding(context, db.CreateBuildingParams{
-			PortID: event.PortID,
-			Type:   event.BuildingType,
-		})
-		if err != nil {
-			panic(err)
-		}
+	_, err = d.CreateBuilding(ctx, db.CreateBuildingParams{
+		PortID: payload.PortID,
+		Type:   payload.BuildingType,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create building: %w", err)
 	}
+
+	return nil
 }
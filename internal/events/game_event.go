@@ -1,15 +1,85 @@
 package events
 
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
 type GameEventType int
 
 const (
 	GameEventPortBuilding GameEventType = iota
 	GameEventResourceCollect
 	GameEventShipConstruct
+	GameEventBuildingDemolish
+	GameEventFleetArrival
+	GameEventMarketOrderExpire
+	GameEventFleetEngagement
+	GameEventPortRaid
 )
 
+func (t GameEventType) String() string {
+	switch t {
+	case GameEventPortBuilding:
+		return "port_building"
+	case GameEventResourceCollect:
+		return "resource_collect"
+	case GameEventShipConstruct:
+		return "ship_construct"
+	case GameEventBuildingDemolish:
+		return "building_demolish"
+	case GameEventFleetArrival:
+		return "fleet_arrival"
+	case GameEventMarketOrderExpire:
+		return "market_order_expire"
+	case GameEventFleetEngagement:
+		return "fleet_engagement"
+	case GameEventPortRaid:
+		return "port_raid"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(t))
+	}
+}
+
+// GameEvent is the envelope stored in the game event queue. The payload is
+// kept raw until the registry hands it to the handler for its event type.
+// ID doubles as the idempotency key: scheduling an ID that is already queued
+// or was recently processed is a no-op. Attempts counts failed deliveries;
+// once it reaches MaxAttempts the event is moved to the dead letter store.
+// Events left without a MaxAttempts get the queue's when they are scheduled.
 type GameEvent struct {
-	PortID       int32         `json:"port_id"`
-	BuildingType string        `json:"building_type"`
-	EventType    GameEventType `json:"event_type"`
+	ID          string          `json:"id"`
+	EventType   GameEventType   `json:"event_type"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	LastError   string          `json:"last_error,omitempty"`
+}
+
+func NewEvent[P any](eventType GameEventType, payload P) (GameEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return GameEvent{}, fmt.Errorf("failed to marshal %s payload: %w", eventType, err)
+	}
+
+	id, err := newEventID()
+	if err != nil {
+		return GameEvent{}, err
+	}
+
+	return GameEvent{
+		ID:        id,
+		EventType: eventType,
+		Payload:   data,
+	}, nil
+}
+
+func newEventID() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate event id: %w", err)
+	}
+	return hex.EncodeToString(bytes), nil
 }
@@ -0,0 +1,467 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	queueKey      = "game_events"
+	processingKey = "game_events:processing"
+	dataKey       = "game_events:data"
+	doneKeyPrefix = "game_events:done:"
+	deadKey       = "game_events:dead"
+	deadIndexKey  = "game_events:dead:index"
+
+	// How long a processed event ID is remembered for de-duplication.
+	doneTTL = 24 * time.Hour
+)
+
+var ErrDeadEventNotFound = errors.New("dead event not found")
+
+// RetryPolicy controls how failed events are retried. The delay doubles
+// with every attempt, starting at BaseDelay and capped at MaxDelay.
+// MaxAttempts applies to events that do not carry their own limit.
+type RetryPolicy struct {
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	MaxAttempts int
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	BaseDelay:   5 * time.Second,
+	MaxDelay:    10 * time.Minute,
+	MaxAttempts: 5,
+}
+
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxDelay)
+}
+
+// Queue is a Redis backed schedule of game events with at-least-once
+// delivery. Event IDs live in a sorted set scored by due time and the event
+// bodies live in a hash keyed by ID. Claiming an event moves it into a
+// processing set scored by its lease expiry, so an event whose worker dies
+// becomes visible again once the lease runs out. Events that keep failing
+// end up in a dead letter store where an admin can inspect, requeue or
+// discard them.
+type Queue struct {
+	redis             *redis.Client
+	visibilityTimeout time.Duration
+	retry             RetryPolicy
+}
+
+func NewQueue(redis *redis.Client, visibilityTimeout time.Duration, retry RetryPolicy) *Queue {
+	return &Queue{
+		redis:             redis,
+		visibilityTimeout: visibilityTimeout,
+		retry:             retry,
+	}
+}
+
+// DeadEvent is an event that was given up on. Raw holds the stored body as
+// it was, since it may not decode into a GameEvent.
+type DeadEvent struct {
+	ID       string     `json:"id"`
+	Raw      string     `json:"raw"`
+	Event    *GameEvent `json:"event,omitempty"`
+	Reason   string     `json:"reason"`
+	FailedAt time.Time  `json:"failed_at"`
+}
+
+// Delivery is a claimed event. Err is set when the stored body could not be
+// decoded; the delivery still has to be acked to remove it.
+type Delivery struct {
+	ID    string
+	Raw   string
+	Event GameEvent
+	Err   error
+}
+
+var scheduleScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[3]) == 1 then
+	return 0
+end
+if redis.call('HSETNX', KEYS[2], ARGV[1], ARGV[3]) == 0 then
+	return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+return 1
+`)
+
+var claimScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[3])
+local claimed = {}
+for _, id in ipairs(ids) do
+	redis.call('ZREM', KEYS[1], id)
+	redis.call('ZADD', KEYS[2], ARGV[2], id)
+	table.insert(claimed, id)
+	table.insert(claimed, redis.call('HGET', KEYS[3], id) or '')
+end
+return claimed
+`)
+
+var ackScript = redis.NewScript(`
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('ZREM', KEYS[4], ARGV[1])
+redis.call('HDEL', KEYS[2], ARGV[1])
+redis.call('SET', KEYS[3], '1', 'EX', ARGV[2])
+return 1
+`)
+
+var rescheduleScript = redis.NewScript(`
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[3])
+redis.call('ZADD', KEYS[3], ARGV[2], ARGV[1])
+return 1
+`)
+
+var deadLetterScript = redis.NewScript(`
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('HDEL', KEYS[2], ARGV[1])
+redis.call('HSET', KEYS[3], ARGV[1], ARGV[3])
+redis.call('ZADD', KEYS[4], ARGV[2], ARGV[1])
+return 1
+`)
+
+var requeueDeadScript = redis.NewScript(`
+if redis.call('HDEL', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('ZREM', KEYS[2], ARGV[1])
+redis.call('HSET', KEYS[3], ARGV[1], ARGV[3])
+redis.call('ZADD', KEYS[4], ARGV[2], ARGV[1])
+return 1
+`)
+
+var discardDeadScript = redis.NewScript(`
+redis.call('ZREM', KEYS[2], ARGV[1])
+return redis.call('HDEL', KEYS[1], ARGV[1])
+`)
+
+var recoverScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+for _, id in ipairs(ids) do
+	redis.call('ZREM', KEYS[1], id)
+	redis.call('ZADD', KEYS[2], ARGV[1], id)
+end
+return #ids
+`)
+
+var extendScript = redis.NewScript(`
+if not redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+return 1
+`)
+
+// Schedule queues the event to become due at the given time. Scheduling an
+// event whose ID is already queued or was processed recently does nothing.
+// An event without its own MaxAttempts takes the queue's retry policy.
+func (q *Queue) Schedule(ctx context.Context, event GameEvent, at time.Time) error {
+	if event.ID == "" {
+		return errors.New("event has no id")
+	}
+	if event.MaxAttempts <= 0 {
+		event.MaxAttempts = q.retry.MaxAttempts
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	err = scheduleScript.Run(ctx, q.redis,
+		[]string{queueKey, dataKey, doneKeyPrefix + event.ID},
+		event.ID, at.UnixMilli(), data,
+	).Err()
+	if err != nil {
+		return fmt.Errorf("failed to schedule event %s: %w", event.ID, err)
+	}
+	return nil
+}
+
+// Claim atomically takes up to count due events and leases them to the
+// caller for the visibility timeout.
+func (q *Queue) Claim(ctx context.Context, now time.Time, count int) ([]Delivery, error) {
+	leaseExpiry := now.Add(q.visibilityTimeout)
+
+	result, err := claimScript.Run(ctx, q.redis,
+		[]string{queueKey, processingKey, dataKey},
+		now.UnixMilli(), leaseExpiry.UnixMilli(), count,
+	).StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim events: %w", err)
+	}
+
+	deliveries := make([]Delivery, 0, len(result)/2)
+	for i := 0; i+1 < len(result); i += 2 {
+		delivery := Delivery{ID: result[i], Raw: result[i+1]}
+		if delivery.Raw == "" {
+			delivery.Err = fmt.Errorf("event %s has no stored body", delivery.ID)
+		} else if err := json.Unmarshal([]byte(delivery.Raw), &delivery.Event); err != nil {
+			delivery.Err = fmt.Errorf("failed to unmarshal event %s: %w", delivery.ID, err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+// Ack removes a claimed event, along with any copy still waiting to come
+// due, and remembers its ID so redeliveries and duplicate schedules are
+// skipped.
+func (q *Queue) Ack(ctx context.Context, id string) error {
+	err := ackScript.Run(ctx, q.redis,
+		[]string{processingKey, dataKey, doneKeyPrefix + id, queueKey},
+		id, int(doneTTL.Seconds()),
+	).Err()
+	if err != nil {
+		return fmt.Errorf("failed to ack event %s: %w", id, err)
+	}
+	return nil
+}
+
+// Extend renews the lease on a claimed event for another visibility
+// timeout from now. It reports false if the event is no longer claimed,
+// because it was acked, retried or its lease already ran out and it was
+// recovered.
+func (q *Queue) Extend(ctx context.Context, id string, now time.Time) (bool, error) {
+	extended, err := extendScript.Run(ctx, q.redis,
+		[]string{processingKey},
+		id, now.Add(q.visibilityTimeout).UnixMilli(),
+	).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to extend lease on event %s: %w", id, err)
+	}
+	return extended == 1, nil
+}
+
+// Hold keeps extending the lease on a claimed event until the returned
+// function is called, so a slow handler's event isn't recovered and run
+// again while the first run is still going. If the lease can't be renewed
+// the event is redelivered as usual, which is why handlers still have to
+// be idempotent.
+func (q *Queue) Hold(ctx context.Context, id string) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(q.visibilityTimeout / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				extended, err := q.Extend(ctx, id, now)
+				if err != nil || !extended {
+					return
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// IsProcessed reports whether an event with this ID was acked recently.
+func (q *Queue) IsProcessed(ctx context.Context, id string) (bool, error) {
+	n, err := q.redis.Exists(ctx, doneKeyPrefix+id).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check event %s: %w", id, err)
+	}
+	return n > 0, nil
+}
+
+// RecoverExpired makes claimed events whose lease has run out due again.
+// It returns the number of events recovered.
+func (q *Queue) RecoverExpired(ctx context.Context, now time.Time) (int, error) {
+	n, err := recoverScript.Run(ctx, q.redis,
+		[]string{processingKey, queueKey},
+		now.UnixMilli(),
+	).Int()
+	if err != nil {
+		return 0, fmt.Errorf("failed to recover expired events: %w", err)
+	}
+	return n, nil
+}
+
+// Retry records a failed delivery of a claimed event. The event is
+// rescheduled with exponential backoff, or moved to the dead letter store
+// once it has used up its attempts. It reports whether the event went dead.
+func (q *Queue) Retry(ctx context.Context, event GameEvent, cause error, now time.Time) (bool, error) {
+	event.Attempts++
+	event.LastError = cause.Error()
+
+	maxAttempts := event.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = q.retry.MaxAttempts
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	if event.Attempts >= maxAttempts {
+		reason := fmt.Sprintf("gave up after %d attempts: %s", event.Attempts, event.LastError)
+		return true, q.DeadLetter(ctx, event.ID, string(data), reason, now)
+	}
+
+	retryAt := now.Add(q.retry.delay(event.Attempts))
+	err = rescheduleScript.Run(ctx, q.redis,
+		[]string{processingKey, dataKey, queueKey},
+		event.ID, retryAt.UnixMilli(), data,
+	).Err()
+	if err != nil {
+		return false, fmt.Errorf("failed to reschedule event %s: %w", event.ID, err)
+	}
+	return false, nil
+}
+
+// DeadLetter moves a claimed event out of the queue and into the dead letter
+// store.
+func (q *Queue) DeadLetter(ctx context.Context, id string, raw string, reason string, now time.Time) error {
+	data, err := json.Marshal(DeadEvent{
+		ID:       id,
+		Raw:      raw,
+		Reason:   reason,
+		FailedAt: now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead event: %w", err)
+	}
+
+	err = deadLetterScript.Run(ctx, q.redis,
+		[]string{processingKey, dataKey, deadKey, deadIndexKey},
+		id, now.UnixMilli(), data,
+	).Err()
+	if err != nil {
+		return fmt.Errorf("failed to dead letter event %s: %w", id, err)
+	}
+	return nil
+}
+
+// ListDead returns dead events, most recent first, along with the total
+// number of dead events.
+func (q *Queue) ListDead(ctx context.Context, offset, limit int64) ([]DeadEvent, int64, error) {
+	total, err := q.redis.ZCard(ctx, deadIndexKey).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count dead events: %w", err)
+	}
+
+	ids, err := q.redis.ZRevRange(ctx, deadIndexKey, offset, offset+limit-1).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list dead events: %w", err)
+	}
+	if len(ids) == 0 {
+		return []DeadEvent{}, total, nil
+	}
+
+	values, err := q.redis.HMGet(ctx, deadKey, ids...).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get dead events: %w", err)
+	}
+
+	deadEvents := make([]DeadEvent, 0, len(values))
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		deadEvent, err := decodeDeadEvent(data)
+		if err != nil {
+			return nil, 0, err
+		}
+		deadEvents = append(deadEvents, deadEvent)
+	}
+
+	return deadEvents, total, nil
+}
+
+func (q *Queue) GetDead(ctx context.Context, id string) (DeadEvent, error) {
+	data, err := q.redis.HGet(ctx, deadKey, id).Result()
+	if errors.Is(err, redis.Nil) {
+		return DeadEvent{}, ErrDeadEventNotFound
+	}
+	if err != nil {
+		return DeadEvent{}, fmt.Errorf("failed to get dead event %s: %w", id, err)
+	}
+	return decodeDeadEvent(data)
+}
+
+// RequeueDead puts a dead event back on the queue, due at the given time,
+// with its attempt count reset.
+func (q *Queue) RequeueDead(ctx context.Context, id string, at time.Time) error {
+	deadEvent, err := q.GetDead(ctx, id)
+	if err != nil {
+		return err
+	}
+	if deadEvent.Event == nil {
+		return fmt.Errorf("dead event %s cannot be decoded and can only be discarded", id)
+	}
+
+	event := *deadEvent.Event
+	event.Attempts = 0
+	event.LastError = ""
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	requeued, err := requeueDeadScript.Run(ctx, q.redis,
+		[]string{deadKey, deadIndexKey, dataKey, queueKey},
+		id, at.UnixMilli(), data,
+	).Int()
+	if err != nil {
+		return fmt.Errorf("failed to requeue dead event %s: %w", id, err)
+	}
+	if requeued == 0 {
+		return ErrDeadEventNotFound
+	}
+	return nil
+}
+
+func (q *Queue) DiscardDead(ctx context.Context, id string) error {
+	discarded, err := discardDeadScript.Run(ctx, q.redis,
+		[]string{deadKey, deadIndexKey},
+		id,
+	).Int()
+	if err != nil {
+		return fmt.Errorf("failed to discard dead event %s: %w", id, err)
+	}
+	if discarded == 0 {
+		return ErrDeadEventNotFound
+	}
+	return nil
+}
+
+func decodeDeadEvent(data string) (DeadEvent, error) {
+	var deadEvent DeadEvent
+	if err := json.Unmarshal([]byte(data), &deadEvent); err != nil {
+		return DeadEvent{}, fmt.Errorf("failed to unmarshal dead event: %w", err)
+	}
+
+	var event GameEvent
+	if err := json.Unmarshal([]byte(deadEvent.Raw), &event); err == nil {
+		deadEvent.Event = &event
+	}
+	return deadEvent, nil
+}
@@ -0,0 +1,71 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrUnknownEventType = errors.New("no handler registered for event type")
+
+type HandlerFunc func(ctx context.Context, event GameEvent) error
+
+// Registry maps each GameEventType to the handler that processes it.
+// Gameplay systems register their own events so the engine never needs to
+// know about them.
+type Registry struct {
+	mu       sync.RWMutex
+	handlers map[GameEventType]HandlerFunc
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		handlers: make(map[GameEventType]HandlerFunc),
+	}
+}
+
+// Handle registers a handler that works on the raw event envelope.
+// Registering the same event type twice panics, like http.ServeMux.
+func (r *Registry) Handle(eventType GameEventType, handler HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.handlers[eventType]; exists {
+		panic(fmt.Sprintf("events: handler already registered for %s", eventType))
+	}
+	r.handlers[eventType] = handler
+}
+
+// Register registers a handler that receives the event payload decoded
+// into P.
+func Register[P any](r *Registry, eventType GameEventType, handle func(ctx context.Context, payload P) error) {
+	r.Handle(eventType, func(ctx context.Context, event GameEvent) error {
+		var payload P
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return fmt.Errorf("failed to decode %s payload: %w", eventType, err)
+		}
+		return handle(ctx, payload)
+	})
+}
+
+// Dispatch runs the handler registered for the event's type. Events without
+// a handler return ErrUnknownEventType, and a handler that panics has the
+// panic returned as an error so it cannot take down the tick loop.
+func (r *Registry) Dispatch(ctx context.Context, event GameEvent) (err error) {
+	r.mu.RLock()
+	handler, ok := r.handlers[event.EventType]
+	r.mu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownEventType, event.EventType)
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("%s handler panicked: %v", event.EventType, recovered)
+		}
+	}()
+	return handler(ctx, event)
+}
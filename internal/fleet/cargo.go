@@ -0,0 +1,265 @@
+package fleet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bradcypert/stserver/internal/db"
+	"github.com/bradcypert/stserver/internal/island"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrCargoEmpty         = errors.New("no cargo given")
+	ErrCargoHoldFull      = errors.New("not enough room in the cargo hold")
+	ErrInsufficientCargo  = errors.New("fleet is not carrying enough cargo")
+	ErrFleetHasCargo      = errors.New("fleet is still carrying cargo")
+	ErrNotPlayerPort      = errors.New("cargo can only be loaded at your own port")
+	ErrInvalidCargoAmount = errors.New("cargo amounts must be positive")
+)
+
+func cargoByResource(cargo []db.FleetCargo) island.Resources {
+	byResource := make(island.Resources, len(cargo))
+	for _, c := range cargo {
+		byResource[c.ResourceType] += c.Amount
+	}
+	return byResource
+}
+
+func cargoTotal(cargo island.Resources) int32 {
+	var total int32
+	for _, amount := range cargo {
+		total += amount
+	}
+	return total
+}
+
+func validateCargo(amounts island.Resources) error {
+	if len(amounts) == 0 {
+		return ErrCargoEmpty
+	}
+	for resourceType, amount := range amounts {
+		if amount <= 0 {
+			return fmt.Errorf("%w: %s", ErrInvalidCargoAmount, resourceType)
+		}
+	}
+	return nil
+}
+
+// sortedResources returns the resource types in amounts in a fixed order, so
+// rows are always locked and written in the same order.
+func sortedResources(amounts island.Resources) []string {
+	resourceTypes := make([]string, 0, len(amounts))
+	for resourceType := range amounts {
+		resourceTypes = append(resourceTypes, resourceType)
+	}
+	slices.Sort(resourceTypes)
+	return resourceTypes
+}
+
+func addCargo(ctx context.Context, q *db.Queries, fleetID int32, amounts island.Resources) error {
+	for _, resourceType := range sortedResources(amounts) {
+		if amounts[resourceType] == 0 {
+			continue
+		}
+
+		err := q.AddFleetCargo(ctx, db.AddFleetCargoParams{
+			FleetID:      fleetID,
+			ResourceType: resourceType,
+			Amount:       amounts[resourceType],
+		})
+		if err != nil {
+			return fmt.Errorf("failed to load %s: %w", resourceType, err)
+		}
+	}
+	return nil
+}
+
+// dockedPlayerFleet locks one of a player's fleets and loads its ships and
+// cargo, failing unless the fleet is docked.
+func (s *Service) dockedPlayerFleet(ctx context.Context, q *db.Queries, playerID, fleetID int32) (*Fleet, error) {
+	fleet, err := lockPlayerFleet(ctx, q, playerID, fleetID)
+	if err != nil {
+		return nil, err
+	}
+	if fleet.Status != fleetStatusDocked || !fleet.PortID.Valid {
+		return nil, ErrFleetNotDocked
+	}
+
+	return s.loadFleet(ctx, q, fleet, time.Now())
+}
+
+// LoadCargo moves resources from the player's own port into the hold of a
+// fleet docked there. The whole load has to fit in the fleet's remaining
+// cargo capacity, and the port's ledger records it leaving.
+func (s *Service) LoadCargo(ctx context.Context, playerID, fleetID int32, amounts island.Resources) (*Fleet, error) {
+	err := validateCargo(amounts)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.queries.WithTx(tx)
+
+	fleet, err := s.dockedPlayerFleet(ctx, q, playerID, fleetID)
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := q.GetPortById(ctx, fleet.PortID.Int32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get port: %w", err)
+	}
+	if port.PlayerID != playerID {
+		return nil, ErrNotPlayerPort
+	}
+
+	free := fleet.CargoCapacity - cargoTotal(fleet.Cargo)
+	if load := cargoTotal(amounts); load > free {
+		return nil, fmt.Errorf("%w: loading %d with room for %d", ErrCargoHoldFull, load, free)
+	}
+
+	err = s.islands.Spend(ctx, tx, port.ID, amounts, island.LedgerCargoLoad, island.LedgerReference(fleet.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	err = addCargo(ctx, q, fleet.ID, amounts)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.commitCargo(ctx, tx, q, fleet)
+}
+
+// UnloadCargo moves resources out of a docked fleet's hold into the port it
+// is docked at, which doesn't have to be the player's own. The port's
+// ledger records it arriving.
+func (s *Service) UnloadCargo(ctx context.Context, playerID, fleetID int32, amounts island.Resources) (*Fleet, error) {
+	err := validateCargo(amounts)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.queries.WithTx(tx)
+
+	fleet, err := s.dockedPlayerFleet(ctx, q, playerID, fleetID)
+	if err != nil {
+		return nil, err
+	}
+
+	var short []string
+	for _, resourceType := range sortedResources(amounts) {
+		if fleet.Cargo[resourceType] < amounts[resourceType] {
+			short = append(short, fmt.Sprintf("%s (need %d, have %d)", resourceType, amounts[resourceType], fleet.Cargo[resourceType]))
+		}
+	}
+	if len(short) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInsufficientCargo, strings.Join(short, ", "))
+	}
+
+	for _, resourceType := range sortedResources(amounts) {
+		removed, err := q.RemoveFleetCargo(ctx, db.RemoveFleetCargoParams{
+			FleetID:      fleet.ID,
+			ResourceType: resourceType,
+			Amount:       amounts[resourceType],
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to unload %s: %w", resourceType, err)
+		}
+		if removed == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrInsufficientCargo, resourceType)
+		}
+	}
+
+	err = q.DeleteEmptyFleetCargo(ctx, fleet.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clear empty cargo: %w", err)
+	}
+
+	err = s.islands.Deposit(ctx, tx, fleet.PortID.Int32, amounts, island.LedgerCargoUnload, island.LedgerReference(fleet.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	return s.commitCargo(ctx, tx, q, fleet)
+}
+
+// commitCargo commits a cargo change and returns the fleet as it now is.
+func (s *Service) commitCargo(ctx context.Context, tx pgx.Tx, q *db.Queries, fleet *Fleet) (*Fleet, error) {
+	result, err := s.loadFleet(ctx, q, fleet.Fleet, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// CaptureCargo empties the hold of a beaten fleet as part of tx. The
+// captor takes as much as it has room for, sharing the space out in
+// proportion to what was carried, and the rest goes down with the ship. It
+// returns what was captured. captorID may be zero when nobody is left to
+// take it, in which case everything is lost.
+func (s *Service) CaptureCargo(ctx context.Context, tx pgx.Tx, fleetID, captorID int32) (island.Resources, error) {
+	q := s.queries.WithTx(tx)
+
+	cargo, err := q.ClearFleetCargo(ctx, fleetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clear cargo: %w", err)
+	}
+
+	captured := island.Resources{}
+	if captorID == 0 || len(cargo) == 0 {
+		return captured, nil
+	}
+
+	captor, err := q.GetFleet(ctx, captorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get capturing fleet: %w", err)
+	}
+
+	hold, err := s.loadFleet(ctx, q, captor, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	carried := cargoByResource(cargo)
+	total := cargoTotal(carried)
+	free := max(hold.CargoCapacity-cargoTotal(hold.Cargo), 0)
+	for _, resourceType := range sortedResources(carried) {
+		amount := carried[resourceType]
+		if total > free {
+			amount = int32(int64(amount) * int64(free) / int64(total))
+		}
+		if amount > 0 {
+			captured[resourceType] = amount
+		}
+	}
+
+	err = addCargo(ctx, q, captor.ID, captured)
+	if err != nil {
+		return nil, err
+	}
+
+	return captured, nil
+}
@@ -0,0 +1,351 @@
+package fleet
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/bradcypert/stserver/internal/combat"
+	"github.com/bradcypert/stserver/internal/db"
+	"github.com/bradcypert/stserver/internal/events"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var ErrBattleNotFound = errors.New("battle not found")
+
+// EngagementPayload is a scheduled fight between two fleets. The seed is
+// picked when the engagement is scheduled so the battle can be replayed,
+// and EngagementID keeps it from being fought twice.
+type EngagementPayload struct {
+	EngagementID    string `json:"engagement_id"`
+	AttackerFleetID int32  `json:"attacker_fleet_id"`
+	DefenderFleetID int32  `json:"defender_fleet_id"`
+	Seed            uint64 `json:"seed"`
+}
+
+// Battle is a battle report as players see it.
+type Battle struct {
+	ID               int32              `json:"id"`
+	AttackerPlayerID int32              `json:"attacker_player_id"`
+	DefenderPlayerID int32              `json:"defender_player_id"`
+	AttackerFleetID  pgtype.Int4        `json:"attacker_fleet_id"`
+	DefenderFleetID  pgtype.Int4        `json:"defender_fleet_id"`
+	PortID           pgtype.Int4        `json:"port_id"`
+	Position         Position           `json:"position"`
+	Outcome          combat.Outcome     `json:"outcome"`
+	Report           combat.Report      `json:"report"`
+	FoughtAt         pgtype.Timestamptz `json:"fought_at"`
+}
+
+func newBattle(row db.BattleReport) (Battle, error) {
+	battle := Battle{
+		ID:               row.ID,
+		AttackerPlayerID: row.AttackerPlayerID,
+		DefenderPlayerID: row.DefenderPlayerID,
+		AttackerFleetID:  row.AttackerFleetID,
+		DefenderFleetID:  row.DefenderFleetID,
+		PortID:           row.PortID,
+		Position:         Position{X: row.X, Y: row.Y},
+		Outcome:          combat.Outcome(row.Outcome),
+		FoughtAt:         row.FoughtAt,
+	}
+
+	err := json.Unmarshal(row.Report, &battle.Report)
+	if err != nil {
+		return battle, fmt.Errorf("failed to read battle report %d: %w", row.ID, err)
+	}
+	return battle, nil
+}
+
+func newEngagementID() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := crand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate engagement id: %w", err)
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// scheduleEngagements starts a fight between a fleet that just arrived at
+// (x, y) and every fleet already there whose faction is hostile to its
+// own, whether anchored in open water or docked at a port. Each fight is
+// its own game event, fought in turn, so a fleet beaten in the first won't
+// be around for the rest.
+func (s *Service) scheduleEngagements(ctx context.Context, q *db.Queries, fleet db.Fleet, x, y float64) error {
+	player, err := q.GetPlayerByID(ctx, fleet.PlayerID)
+	if err != nil {
+		return fmt.Errorf("failed to get player: %w", err)
+	}
+
+	hostiles, err := q.GetHostileFleetsAt(ctx, db.GetHostileFleetsAtParams{
+		FactionID: player.Faction,
+		X:         x,
+		Y:         y,
+		PlayerID:  fleet.PlayerID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find hostile fleets: %w", err)
+	}
+
+	for _, hostile := range hostiles {
+		engagementID, err := newEngagementID()
+		if err != nil {
+			return err
+		}
+
+		event, err := events.NewEvent(events.GameEventFleetEngagement, EngagementPayload{
+			EngagementID:    engagementID,
+			AttackerFleetID: fleet.ID,
+			DefenderFleetID: hostile.ID,
+			Seed:            rand.Uint64(),
+		})
+		if err != nil {
+			return err
+		}
+
+		err = s.events.Schedule(ctx, event, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to schedule engagement: %w", err)
+		}
+	}
+	return nil
+}
+
+func combatSide(ships []db.GetFleetShipsRow) combat.Side {
+	side := combat.Side{Ships: make([]combat.Ship, 0, len(ships))}
+	for _, ship := range ships {
+		side.Ships = append(side.Ships, combat.Ship{
+			ID:      ship.ID,
+			Name:    ship.Name,
+			Class:   ship.ShipClass,
+			Hull:    ship.Hull,
+			MaxHull: ship.MaxHull,
+			Cannons: ship.Cannons,
+			Crew:    ship.Crew,
+			MaxCrew: ship.MaxCrew,
+		})
+	}
+	return side
+}
+
+// applyShipResults writes the damage and crew losses from a battle back to
+// the ships and sends the sunk ones to the bottom.
+func applyShipResults(ctx context.Context, q *db.Queries, side combat.SideReport) error {
+	for _, ship := range side.Ships {
+		if ship.Sunk {
+			err := q.DeleteShip(ctx, ship.ID)
+			if err != nil {
+				return fmt.Errorf("failed to sink ship %d: %w", ship.ID, err)
+			}
+			continue
+		}
+
+		err := q.UpdateShipDamage(ctx, db.UpdateShipDamageParams{
+			ID:   ship.ID,
+			Hull: ship.Hull,
+			Crew: ship.Crew,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to damage ship %d: %w", ship.ID, err)
+		}
+	}
+	return nil
+}
+
+// lockEngagedFleets locks both fleets in id order, so two engagements
+// between the same fleets can't deadlock.
+func lockEngagedFleets(ctx context.Context, q *db.Queries, attackerID, defenderID int32) (db.Fleet, db.Fleet, error) {
+	first, second := min(attackerID, defenderID), max(attackerID, defenderID)
+
+	locked := make(map[int32]db.Fleet, 2)
+	for _, id := range []int32{first, second} {
+		fleet, err := q.LockFleet(ctx, id)
+		if err != nil {
+			return db.Fleet{}, db.Fleet{}, err
+		}
+		locked[id] = fleet
+	}
+	return locked[attackerID], locked[defenderID], nil
+}
+
+// HandleFleetEngagementEvent fights a scheduled engagement. It only goes
+// ahead if both fleets still exist, are in the same place and not under
+// sail, and their factions are still hostile. Damage and sinkings are
+// written back to the ships, the winner captures what it can carry of the
+// loser's cargo, fleets with no ships left are removed, and a battle report
+// is recorded for both players. It is safe to run more than once for the
+// same engagement.
+func (s *Service) HandleFleetEngagementEvent(ctx context.Context, payload EngagementPayload) error {
+	fought, err := s.queries.BattleReportExists(ctx, payload.EngagementID)
+	if err != nil {
+		return fmt.Errorf("failed to check battle report: %w", err)
+	}
+	if fought {
+		return nil
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.queries.WithTx(tx)
+
+	attacker, defender, err := lockEngagedFleets(ctx, q, payload.AttackerFleetID, payload.DefenderFleetID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get fleets: %w", err)
+	}
+
+	if attacker.Status == fleetStatusSailing || defender.Status == fleetStatusSailing ||
+		attacker.X != defender.X || attacker.Y != defender.Y {
+		return nil
+	}
+
+	hostile, err := s.factionsHostile(ctx, q, attacker.PlayerID, defender.PlayerID)
+	if err != nil {
+		return err
+	}
+	if !hostile {
+		return nil
+	}
+
+	now := time.Now()
+	attacking, err := s.loadFleet(ctx, q, attacker, now)
+	if err != nil {
+		return err
+	}
+	defending, err := s.loadFleet(ctx, q, defender, now)
+	if err != nil {
+		return err
+	}
+	if len(attacking.Ships) == 0 || len(defending.Ships) == 0 {
+		return nil
+	}
+
+	report := combat.Resolve(combatSide(attacking.Ships), combatSide(defending.Ships), payload.Seed)
+
+	err = applyShipResults(ctx, q, report.Attacker)
+	if err != nil {
+		return err
+	}
+	err = applyShipResults(ctx, q, report.Defender)
+	if err != nil {
+		return err
+	}
+
+	switch report.Outcome {
+	case combat.OutcomeAttacker:
+		report.Captured, err = s.CaptureCargo(ctx, tx, defender.ID, attacker.ID)
+	case combat.OutcomeDefender:
+		report.Captured, err = s.CaptureCargo(ctx, tx, attacker.ID, defender.ID)
+	}
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to write battle report: %w", err)
+	}
+
+	_, err = q.CreateBattleReport(ctx, db.CreateBattleReportParams{
+		EngagementID:     payload.EngagementID,
+		AttackerPlayerID: attacker.PlayerID,
+		DefenderPlayerID: defender.PlayerID,
+		AttackerFleetID:  pgtype.Int4{Int32: attacker.ID, Valid: true},
+		DefenderFleetID:  pgtype.Int4{Int32: defender.ID, Valid: true},
+		PortID:           defender.PortID,
+		X:                defender.X,
+		Y:                defender.Y,
+		Outcome:          string(report.Outcome),
+		Report:           data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record battle report: %w", err)
+	}
+
+	// Whatever cargo wasn't captured goes down with the fleet
+	for _, side := range []struct {
+		fleet  db.Fleet
+		report combat.SideReport
+	}{{attacker, report.Attacker}, {defender, report.Defender}} {
+		if side.report.Afloat() {
+			continue
+		}
+
+		err = q.DeleteFleet(ctx, side.fleet.ID)
+		if err != nil {
+			return fmt.Errorf("failed to remove sunk fleet: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// factionsHostile reports whether two players' factions are at war.
+func (s *Service) factionsHostile(ctx context.Context, q *db.Queries, playerID, otherPlayerID int32) (bool, error) {
+	player, err := q.GetPlayerByID(ctx, playerID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get player: %w", err)
+	}
+
+	other, err := q.GetPlayerByID(ctx, otherPlayerID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get player: %w", err)
+	}
+
+	hostile, err := q.AreFactionsHostile(ctx, db.AreFactionsHostileParams{
+		FactionID:      player.Faction,
+		OtherFactionID: other.Faction,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to get faction relations: %w", err)
+	}
+	return hostile, nil
+}
+
+// GetPlayerBattles returns the most recent battles a player fought in, on
+// either side.
+func (s *Service) GetPlayerBattles(ctx context.Context, playerID int32) ([]Battle, error) {
+	rows, err := s.queries.GetPlayerBattleReports(ctx, playerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get battle reports: %w", err)
+	}
+
+	battles := make([]Battle, 0, len(rows))
+	for _, row := range rows {
+		battle, err := newBattle(row)
+		if err != nil {
+			return nil, err
+		}
+		battles = append(battles, battle)
+	}
+	return battles, nil
+}
+
+// GetBattle returns a battle report. Only the players who fought in it can
+// read it.
+func (s *Service) GetBattle(ctx context.Context, playerID, battleID int32) (*Battle, error) {
+	row, err := s.queries.GetBattleReport(ctx, battleID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && row.AttackerPlayerID != playerID && row.DefenderPlayerID != playerID) {
+		return nil, ErrBattleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get battle report: %w", err)
+	}
+
+	battle, err := newBattle(row)
+	if err != nil {
+		return nil, err
+	}
+	return &battle, nil
+}
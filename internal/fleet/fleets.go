@@ -0,0 +1,526 @@
+package fleet
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bradcypert/stserver/internal/db"
+	"github.com/bradcypert/stserver/internal/events"
+	"github.com/bradcypert/stserver/internal/island"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	fleetStatusDocked   = "docked"
+	fleetStatusAnchored = "anchored"
+	fleetStatusSailing  = "sailing"
+)
+
+var (
+	ErrFleetNotFound       = errors.New("fleet not found")
+	ErrFleetNameRequired   = errors.New("fleet name is required")
+	ErrFleetEmpty          = errors.New("fleet needs at least one ship")
+	ErrShipUnavailable     = errors.New("ship is not docked at the port or is already in a fleet")
+	ErrFleetNotDocked      = errors.New("fleet is not docked")
+	ErrInvalidDestination  = errors.New("invalid destination")
+	ErrFleetAlreadyArrived = errors.New("fleet is already at its destination")
+)
+
+type FleetArrivalPayload struct {
+	FleetID   int32     `json:"fleet_id"`
+	ArrivesAt time.Time `json:"arrives_at"`
+}
+
+// Position is a point on the world grid. Ports sit on whole coordinates but
+// fleets at sea can be anywhere in between.
+type Position struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+func (p Position) distanceTo(other Position) float64 {
+	return math.Hypot(other.X-p.X, other.Y-p.Y)
+}
+
+// positionAt works out where a fleet is at the given instant. Sailing
+// fleets move in a straight line at constant speed, so their position is
+// interpolated between where they set off and where they're headed.
+func positionAt(fleet db.Fleet, at time.Time) Position {
+	if fleet.Status != fleetStatusSailing {
+		return Position{X: fleet.X, Y: fleet.Y}
+	}
+
+	origin := Position{X: fleet.OriginX.Float64, Y: fleet.OriginY.Float64}
+	target := Position{X: fleet.TargetX.Float64, Y: fleet.TargetY.Float64}
+
+	total := fleet.ArrivesAt.Time.Sub(fleet.DepartedAt.Time)
+	if total <= 0 {
+		return target
+	}
+	progress := float64(at.Sub(fleet.DepartedAt.Time)) / float64(total)
+	progress = min(max(progress, 0), 1)
+
+	return Position{
+		X: origin.X + (target.X-origin.X)*progress,
+		Y: origin.Y + (target.Y-origin.Y)*progress,
+	}
+}
+
+// Fleet is a fleet with its ships, its cargo and where it is at the time it
+// was read. A fleet sails at the speed of its slowest ship and can carry as
+// much as all its ships together.
+type Fleet struct {
+	db.Fleet
+	Position      Position              `json:"position"`
+	Speed         float64               `json:"speed"`
+	CargoCapacity int32                 `json:"cargo_capacity"`
+	Cargo         island.Resources      `json:"cargo"`
+	Ships         []db.GetFleetShipsRow `json:"ships"`
+}
+
+func newFleet(fleet db.Fleet, ships []db.GetFleetShipsRow, cargo []db.FleetCargo, at time.Time) Fleet {
+	f := Fleet{
+		Fleet:    fleet,
+		Position: positionAt(fleet, at),
+		Cargo:    cargoByResource(cargo),
+		Ships:    ships,
+	}
+	if f.Ships == nil {
+		f.Ships = []db.GetFleetShipsRow{}
+	}
+
+	for i, ship := range ships {
+		if i == 0 || ship.Speed < f.Speed {
+			f.Speed = ship.Speed
+		}
+		f.CargoCapacity += ship.CargoCapacity
+	}
+	return f
+}
+
+// FleetSighting is what anyone can see of a fleet at sea.
+type FleetSighting struct {
+	ID        int32              `json:"id"`
+	PlayerID  int32              `json:"player_id"`
+	Name      string             `json:"name"`
+	Status    string             `json:"status"`
+	Position  Position           `json:"position"`
+	ArrivesAt pgtype.Timestamptz `json:"arrives_at"`
+}
+
+// CreateFleetRequest groups ships docked at PortID into a new fleet.
+type CreateFleetRequest struct {
+	PlayerID int32   `json:"player_id"`
+	PortID   int32   `json:"port_id"`
+	Name     string  `json:"name"`
+	ShipIDs  []int32 `json:"ship_ids"`
+}
+
+// DispatchRequest sends a fleet to a port, or to open water at X and Y
+// when PortID is nil. With Raid set the fleet attacks the port instead of
+// docking there.
+type DispatchRequest struct {
+	PortID *int32   `json:"port_id"`
+	X      *float64 `json:"x"`
+	Y      *float64 `json:"y"`
+	Raid   bool     `json:"raid"`
+}
+
+func (s *Service) loadFleet(ctx context.Context, q *db.Queries, fleet db.Fleet, at time.Time) (*Fleet, error) {
+	ships, err := q.GetFleetShips(ctx, pgtype.Int4{Int32: fleet.ID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fleet ships: %w", err)
+	}
+
+	cargo, err := q.GetFleetCargo(ctx, fleet.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fleet cargo: %w", err)
+	}
+
+	f := newFleet(fleet, ships, cargo, at)
+	return &f, nil
+}
+
+// lockPlayerFleet locks a fleet row for the rest of the transaction, making
+// sure it belongs to playerID.
+func lockPlayerFleet(ctx context.Context, q *db.Queries, playerID, fleetID int32) (db.Fleet, error) {
+	fleet, err := q.LockFleet(ctx, fleetID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && fleet.PlayerID != playerID) {
+		return fleet, ErrFleetNotFound
+	}
+	if err != nil {
+		return fleet, fmt.Errorf("failed to get fleet: %w", err)
+	}
+	return fleet, nil
+}
+
+// CreateFleet groups a player's docked ships into a fleet at their port.
+// Ships can only be in one fleet at a time.
+func (s *Service) CreateFleet(ctx context.Context, req CreateFleetRequest) (*Fleet, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, ErrFleetNameRequired
+	}
+	if len(req.ShipIDs) == 0 {
+		return nil, ErrFleetEmpty
+	}
+
+	port, err := s.queries.GetPortById(ctx, req.PortID)
+	if err != nil {
+		return nil, fmt.Errorf("port not found: %w", err)
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.queries.WithTx(tx)
+	portID := pgtype.Int4{Int32: port.ID, Valid: true}
+
+	fleet, err := q.CreateFleet(ctx, db.CreateFleetParams{
+		PlayerID: req.PlayerID,
+		Name:     name,
+		PortID:   portID,
+		X:        float64(port.X),
+		Y:        float64(port.Y),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fleet: %w", err)
+	}
+
+	for _, shipID := range req.ShipIDs {
+		assigned, err := q.AssignShipToFleet(ctx, db.AssignShipToFleetParams{
+			ID:       shipID,
+			FleetID:  pgtype.Int4{Int32: fleet.ID, Valid: true},
+			PlayerID: req.PlayerID,
+			PortID:   portID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to add ship to fleet: %w", err)
+		}
+		if assigned == 0 {
+			return nil, fmt.Errorf("%w: ship %d", ErrShipUnavailable, shipID)
+		}
+	}
+
+	result, err := s.loadFleet(ctx, q, fleet, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DisbandFleet breaks up a docked fleet, leaving its ships in port. Its
+// cargo has to be unloaded first.
+func (s *Service) DisbandFleet(ctx context.Context, playerID, fleetID int32) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.queries.WithTx(tx)
+
+	fleet, err := lockPlayerFleet(ctx, q, playerID, fleetID)
+	if err != nil {
+		return err
+	}
+	if fleet.Status != fleetStatusDocked {
+		return ErrFleetNotDocked
+	}
+
+	cargo, err := q.GetFleetCargo(ctx, fleet.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get fleet cargo: %w", err)
+	}
+	if len(cargo) > 0 {
+		return ErrFleetHasCargo
+	}
+
+	err = q.ReleaseFleetShips(ctx, pgtype.Int4{Int32: fleet.ID, Valid: true})
+	if err != nil {
+		return fmt.Errorf("failed to release ships: %w", err)
+	}
+
+	err = q.DeleteFleet(ctx, fleet.ID)
+	if err != nil {
+		return fmt.Errorf("failed to disband fleet: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DispatchFleet sends a fleet from wherever it is now towards a port or a
+// point in open water, or to raid another player's port. A fleet already
+// under sail changes course from its current position. Travel time is the
+// distance over the speed of the slowest ship, and arrival is a scheduled
+// game event.
+func (s *Service) DispatchFleet(ctx context.Context, playerID, fleetID int32, req DispatchRequest) (*Fleet, error) {
+	var target Position
+	var port db.Port
+	targetPortID := pgtype.Int4{}
+	switch {
+	case req.PortID != nil:
+		var err error
+		port, err = s.queries.GetPortById(ctx, *req.PortID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: port %d not found", ErrInvalidDestination, *req.PortID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get port: %w", err)
+		}
+		target = Position{X: float64(port.X), Y: float64(port.Y)}
+		targetPortID = pgtype.Int4{Int32: port.ID, Valid: true}
+	case req.Raid:
+		return nil, fmt.Errorf("%w: a raid needs a port to attack", ErrInvalidRaidTarget)
+	case req.X != nil && req.Y != nil:
+		target = Position{X: *req.X, Y: *req.Y}
+	default:
+		return nil, fmt.Errorf("%w: a port or coordinates are required", ErrInvalidDestination)
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.queries.WithTx(tx)
+	now := time.Now()
+
+	fleet, err := lockPlayerFleet(ctx, q, playerID, fleetID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.loadFleet(ctx, q, fleet, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(current.Ships) == 0 {
+		return nil, ErrFleetEmpty
+	}
+
+	if req.Raid {
+		err = s.checkRaidTarget(ctx, q, playerID, port, now)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	distance := current.Position.distanceTo(target)
+	if distance == 0 {
+		return nil, ErrFleetAlreadyArrived
+	}
+
+	// Stored at the database's precision so the arrival event can be
+	// matched against the fleet's current voyage
+	travelTime := time.Duration(distance / current.Speed * float64(time.Hour))
+	arrivesAt := now.Add(travelTime).Truncate(time.Microsecond)
+
+	err = q.DispatchFleet(ctx, db.DispatchFleetParams{
+		ID:           fleet.ID,
+		OriginX:      pgtype.Float8{Float64: current.Position.X, Valid: true},
+		OriginY:      pgtype.Float8{Float64: current.Position.Y, Valid: true},
+		TargetX:      pgtype.Float8{Float64: target.X, Valid: true},
+		TargetY:      pgtype.Float8{Float64: target.Y, Valid: true},
+		TargetPortID: targetPortID,
+		DepartedAt:   pgtype.Timestamptz{Time: now, Valid: true},
+		ArrivesAt:    pgtype.Timestamptz{Time: arrivesAt, Valid: true},
+		Raiding:      req.Raid,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to dispatch fleet: %w", err)
+	}
+
+	// Attacking someone gives up the player's own new-player protection
+	if req.Raid {
+		err = q.EndPortProtection(ctx, playerID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to end protection: %w", err)
+		}
+	}
+
+	err = q.SetFleetShipsAtSea(ctx, pgtype.Int4{Int32: fleet.ID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to put ships to sea: %w", err)
+	}
+
+	event, err := events.NewEvent(events.GameEventFleetArrival, FleetArrivalPayload{
+		FleetID:   fleet.ID,
+		ArrivesAt: arrivesAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Scheduled before committing so a fleet never sails forever. Events for
+	// a voyage that was replaced by a new course are ignored on arrival.
+	err = s.events.Schedule(ctx, event, arrivesAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to schedule fleet arrival: %w", err)
+	}
+
+	fleet, err = q.GetFleet(ctx, fleet.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fleet: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	current.Fleet = fleet
+	return current, nil
+}
+
+// HandleFleetArrivalEvent ends a fleet's voyage. Fleets bound for a port
+// dock there; the rest drop anchor at their target. Either way, the fleet
+// engages any hostile fleets it finds there. A raiding fleet instead drops
+// anchor off the port it came for and the raid is scheduled. Events for an
+// earlier voyage, or one that already ended, are ignored.
+func (s *Service) HandleFleetArrivalEvent(ctx context.Context, payload FleetArrivalPayload) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.queries.WithTx(tx)
+
+	fleet, err := q.LockFleet(ctx, payload.FleetID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get fleet: %w", err)
+	}
+
+	if fleet.Status != fleetStatusSailing || !fleet.ArrivesAt.Time.Equal(payload.ArrivesAt) {
+		return nil
+	}
+
+	raid := fleet.Raiding && fleet.TargetPortID.Valid
+
+	status := fleetStatusAnchored
+	portID := pgtype.Int4{}
+	if fleet.TargetPortID.Valid && !raid {
+		status = fleetStatusDocked
+		portID = fleet.TargetPortID
+	}
+
+	err = q.ArriveFleet(ctx, db.ArriveFleetParams{
+		ID:     fleet.ID,
+		Status: status,
+		PortID: portID,
+		X:      fleet.TargetX.Float64,
+		Y:      fleet.TargetY.Float64,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to end voyage: %w", err)
+	}
+
+	if raid {
+		err = s.scheduleRaid(ctx, fleet.ID, fleet.TargetPortID.Int32)
+		if err != nil {
+			return err
+		}
+		return tx.Commit(ctx)
+	}
+
+	if portID.Valid {
+		err = q.DockFleetShips(ctx, db.DockFleetShipsParams{
+			FleetID: pgtype.Int4{Int32: fleet.ID, Valid: true},
+			PortID:  portID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to dock ships: %w", err)
+		}
+	}
+
+	err = s.scheduleEngagements(ctx, q, fleet, fleet.TargetX.Float64, fleet.TargetY.Float64)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetFleet returns one of a player's fleets as it is at the given instant.
+func (s *Service) GetFleet(ctx context.Context, playerID, fleetID int32, at time.Time) (*Fleet, error) {
+	fleet, err := s.queries.GetFleet(ctx, fleetID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && fleet.PlayerID != playerID) {
+		return nil, ErrFleetNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fleet: %w", err)
+	}
+
+	return s.loadFleet(ctx, s.queries, fleet, at)
+}
+
+// GetPlayerFleets returns all of a player's fleets as they are at the given
+// instant.
+func (s *Service) GetPlayerFleets(ctx context.Context, playerID int32, at time.Time) ([]Fleet, error) {
+	fleets, err := s.queries.GetPlayerFleets(ctx, playerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fleets: %w", err)
+	}
+
+	result := make([]Fleet, 0, len(fleets))
+	for _, fleet := range fleets {
+		f, err := s.loadFleet(ctx, s.queries, fleet, at)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *f)
+	}
+	return result, nil
+}
+
+// GetFleetsNear returns every fleet at sea within radius of center at the
+// given instant, nearest first.
+func (s *Service) GetFleetsNear(ctx context.Context, center Position, radius float64, at time.Time) ([]FleetSighting, error) {
+	fleets, err := s.queries.GetFleetsAtSea(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fleets: %w", err)
+	}
+
+	sightings := []FleetSighting{}
+	for _, fleet := range fleets {
+		position := positionAt(fleet, at)
+		if position.distanceTo(center) > radius {
+			continue
+		}
+
+		sightings = append(sightings, FleetSighting{
+			ID:        fleet.ID,
+			PlayerID:  fleet.PlayerID,
+			Name:      fleet.Name,
+			Status:    fleet.Status,
+			Position:  position,
+			ArrivesAt: fleet.ArrivesAt,
+		})
+	}
+
+	slices.SortStableFunc(sightings, func(a, b FleetSighting) int {
+		return cmp.Compare(a.Position.distanceTo(center), b.Position.distanceTo(center))
+	})
+	return sightings, nil
+}
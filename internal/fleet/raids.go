@@ -0,0 +1,408 @@
+package fleet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/bradcypert/stserver/internal/combat"
+	"github.com/bradcypert/stserver/internal/db"
+	"github.com/bradcypert/stserver/internal/events"
+	"github.com/bradcypert/stserver/internal/island"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// raidLootShare is the share of each unprotected resource a successful
+	// raid carries off, if the raiders have room for it.
+	raidLootShare = 0.3
+
+	// A port's fort fights as a single battery: every point of defense
+	// rating is fortHullPerDefense hull, and every fortDefensePerCannon
+	// points is a gun with fortCrewPerCannon gunners.
+	fortClass            = "fort"
+	fortHullPerDefense   = 4
+	fortDefensePerCannon = 5
+	fortCrewPerCannon    = 4
+
+	// A player can raid the same port once every raidCooldown, and a port
+	// loses at most dailyRaidLossCap units to raids in any raidLossWindow.
+	raidCooldown     = 6 * time.Hour
+	raidLossWindow   = 24 * time.Hour
+	dailyRaidLossCap = 5000
+)
+
+var (
+	ErrInvalidRaidTarget = errors.New("invalid raid target")
+	ErrRaidNotFound      = errors.New("raid not found")
+	ErrPortProtected     = errors.New("port is under new-player protection")
+	ErrRaidCooldown      = errors.New("you raided this port too recently")
+	ErrRaidLimitReached  = errors.New("port has lost as much as it can to raids today")
+)
+
+// RaidPayload is a scheduled attack by a fleet on the port it is anchored
+// off. Like an engagement, the seed is picked up front and RaidID keeps the
+// raid from being resolved twice.
+type RaidPayload struct {
+	RaidID  string `json:"raid_id"`
+	FleetID int32  `json:"fleet_id"`
+	PortID  int32  `json:"port_id"`
+	Seed    uint64 `json:"seed"`
+}
+
+// Raid is a raid report as both the raider and the raided player see it.
+// The loot carried off is the report's captured cargo.
+type Raid struct {
+	ID               int32              `json:"id"`
+	AttackerPlayerID int32              `json:"attacker_player_id"`
+	DefenderPlayerID int32              `json:"defender_player_id"`
+	FleetID          pgtype.Int4        `json:"fleet_id"`
+	PortID           int32              `json:"port_id"`
+	DefenseRating    int32              `json:"defense_rating"`
+	Looted           int32              `json:"looted"`
+	Outcome          combat.Outcome     `json:"outcome"`
+	Report           combat.Report      `json:"report"`
+	RaidedAt         pgtype.Timestamptz `json:"raided_at"`
+}
+
+func newRaid(row db.RaidReport) (Raid, error) {
+	raid := Raid{
+		ID:               row.ID,
+		AttackerPlayerID: row.AttackerPlayerID,
+		DefenderPlayerID: row.DefenderPlayerID,
+		FleetID:          row.FleetID,
+		PortID:           row.PortID,
+		DefenseRating:    row.DefenseRating,
+		Looted:           row.Looted,
+		Outcome:          combat.Outcome(row.Outcome),
+		RaidedAt:         row.RaidedAt,
+	}
+
+	err := json.Unmarshal(row.Report, &raid.Report)
+	if err != nil {
+		return raid, fmt.Errorf("failed to read raid report %d: %w", row.ID, err)
+	}
+	return raid, nil
+}
+
+// checkRaidTarget fails unless the player may raid the port at the given
+// instant: it has to belong to another player, be past its new-player
+// protection, not have been raided by the same player within raidCooldown,
+// and not have lost dailyRaidLossCap to raids already today.
+func (s *Service) checkRaidTarget(ctx context.Context, q *db.Queries, playerID int32, port db.Port, at time.Time) error {
+	if port.PlayerID == playerID {
+		return fmt.Errorf("%w: you can't raid your own port", ErrInvalidRaidTarget)
+	}
+
+	if port.ProtectedUntil.Time.After(at) {
+		return fmt.Errorf("%w until %s", ErrPortProtected, port.ProtectedUntil.Time.Format(time.RFC3339))
+	}
+
+	raided, err := q.HasRaidedPortSince(ctx, db.HasRaidedPortSinceParams{
+		AttackerPlayerID: playerID,
+		PortID:           port.ID,
+		RaidedAt:         pgtype.Timestamptz{Time: at.Add(-raidCooldown), Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get recent raids: %w", err)
+	}
+	if raided {
+		return ErrRaidCooldown
+	}
+
+	lost, err := portRaidLosses(ctx, q, port.ID, at)
+	if err != nil {
+		return err
+	}
+	if lost >= dailyRaidLossCap {
+		return ErrRaidLimitReached
+	}
+	return nil
+}
+
+// raidRefused reports whether err is one of the raiding rules rather than
+// something going wrong.
+func raidRefused(err error) bool {
+	return errors.Is(err, ErrInvalidRaidTarget) || errors.Is(err, ErrPortProtected) ||
+		errors.Is(err, ErrRaidCooldown) || errors.Is(err, ErrRaidLimitReached)
+}
+
+// portRaidLosses is how much a port has lost to raids in the raidLossWindow
+// before the given instant.
+func portRaidLosses(ctx context.Context, q *db.Queries, portID int32, at time.Time) (int32, error) {
+	lost, err := q.GetPortRaidLossesSince(ctx, db.GetPortRaidLossesSinceParams{
+		PortID:   portID,
+		RaidedAt: pgtype.Timestamptz{Time: at.Add(-raidLossWindow), Valid: true},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get raid losses: %w", err)
+	}
+	return lost, nil
+}
+
+func (s *Service) scheduleRaid(ctx context.Context, fleetID, portID int32) error {
+	raidID, err := newEngagementID()
+	if err != nil {
+		return err
+	}
+
+	event, err := events.NewEvent(events.GameEventPortRaid, RaidPayload{
+		RaidID:  raidID,
+		FleetID: fleetID,
+		PortID:  portID,
+		Seed:    rand.Uint64(),
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.events.Schedule(ctx, event, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to schedule raid: %w", err)
+	}
+	return nil
+}
+
+// portDefense is the side a port puts up against raiders: its fort, if it
+// has any defense rating, and every ship its owner has docked there.
+func portDefense(defenseRating int32, ships []db.GetPortDefendersRow) combat.Side {
+	side := combat.Side{Ships: make([]combat.Ship, 0, len(ships)+1)}
+
+	if defenseRating > 0 {
+		cannons := max(defenseRating/fortDefensePerCannon, 1)
+		side.Ships = append(side.Ships, combat.Ship{
+			Name:    "Fort",
+			Class:   fortClass,
+			Hull:    defenseRating * fortHullPerDefense,
+			MaxHull: defenseRating * fortHullPerDefense,
+			Cannons: cannons,
+			Crew:    cannons * fortCrewPerCannon,
+			MaxCrew: cannons * fortCrewPerCannon,
+		})
+	}
+
+	for _, ship := range ships {
+		side.Ships = append(side.Ships, combat.Ship{
+			ID:      ship.ID,
+			Name:    ship.Name,
+			Class:   ship.ShipClass,
+			Hull:    ship.Hull,
+			MaxHull: ship.MaxHull,
+			Cannons: ship.Cannons,
+			Crew:    ship.Crew,
+			MaxCrew: ship.MaxCrew,
+		})
+	}
+	return side
+}
+
+// withoutFort leaves the fort out of a side's results. A silenced fort is
+// back in action for the next raid, so there is nothing to write back.
+func withoutFort(side combat.SideReport) combat.SideReport {
+	ships := make([]combat.ShipResult, 0, len(side.Ships))
+	for _, ship := range side.Ships {
+		if ship.Class != fortClass {
+			ships = append(ships, ship)
+		}
+	}
+	side.Ships = ships
+	return side
+}
+
+// HandlePortRaidEvent resolves a raid by a fleet anchored off a port. The
+// raiders fight the port's fort and its owner's docked ships, and damage
+// and sinkings on both sides are written back. If the raiders win they
+// carry off raidLootShare of every resource above what the port's
+// warehouses protect, as much as their holds and the port's daily loss cap
+// allow. Raids the port is no longer open to are called off. A raid report is
+// recorded for both players. Raids whose fleet is gone or has sailed on
+// are dropped, and it is safe to run more than once for the same raid.
+func (s *Service) HandlePortRaidEvent(ctx context.Context, payload RaidPayload) error {
+	raided, err := s.queries.RaidReportExists(ctx, payload.RaidID)
+	if err != nil {
+		return fmt.Errorf("failed to check raid report: %w", err)
+	}
+	if raided {
+		return nil
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.queries.WithTx(tx)
+
+	fleet, err := q.LockFleet(ctx, payload.FleetID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get fleet: %w", err)
+	}
+
+	port, err := q.GetPortById(ctx, payload.PortID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get port: %w", err)
+	}
+
+	if fleet.Status != fleetStatusAnchored || fleet.X != float64(port.X) || fleet.Y != float64(port.Y) {
+		return nil
+	}
+
+	// Raids on the same port queue up here, so each one sees the raids and
+	// losses committed before it
+	_, err = q.LockPortResources(ctx, port.ID)
+	if err != nil {
+		return fmt.Errorf("failed to lock port resources: %w", err)
+	}
+
+	// The rules are checked again in case the port's circumstances changed
+	// while the raiders were at sea
+	now := time.Now()
+	err = s.checkRaidTarget(ctx, q, fleet.PlayerID, port, now)
+	if raidRefused(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	raiders, err := s.loadFleet(ctx, q, fleet, now)
+	if err != nil {
+		return err
+	}
+	if len(raiders.Ships) == 0 {
+		return nil
+	}
+
+	stats, err := s.islands.GetIslandStatsTx(ctx, tx, port.ID)
+	if err != nil {
+		return err
+	}
+
+	defenders, err := q.GetPortDefenders(ctx, db.GetPortDefendersParams{
+		PortID:   pgtype.Int4{Int32: port.ID, Valid: true},
+		PlayerID: port.PlayerID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get port defenders: %w", err)
+	}
+
+	report := combat.Resolve(combatSide(raiders.Ships), portDefense(stats.DefenseRating, defenders), payload.Seed)
+
+	err = applyShipResults(ctx, q, report.Attacker)
+	if err != nil {
+		return err
+	}
+	err = applyShipResults(ctx, q, withoutFort(report.Defender))
+	if err != nil {
+		return err
+	}
+
+	// Fleets docked at the port lose their cargo with their last ship
+	err = q.DeleteEmptyPlayerFleets(ctx, port.PlayerID)
+	if err != nil {
+		return fmt.Errorf("failed to remove sunk fleets: %w", err)
+	}
+
+	if report.Outcome == combat.OutcomeAttacker {
+		// Only the ships still afloat have room for the loot
+		raiders, err = s.loadFleet(ctx, q, fleet, now)
+		if err != nil {
+			return err
+		}
+
+		lost, err := portRaidLosses(ctx, q, port.ID, now)
+		if err != nil {
+			return err
+		}
+
+		room := max(min(raiders.CargoCapacity-cargoTotal(raiders.Cargo), dailyRaidLossCap-lost), 0)
+		var loot island.Resources
+		loot, err = s.islands.Loot(ctx, tx, port.ID, raidLootShare, room, island.LedgerReference(fleet.ID))
+		if err != nil {
+			return fmt.Errorf("failed to loot port: %w", err)
+		}
+
+		err = addCargo(ctx, q, fleet.ID, loot)
+		if err != nil {
+			return err
+		}
+		report.Captured = loot
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to write raid report: %w", err)
+	}
+
+	_, err = q.CreateRaidReport(ctx, db.CreateRaidReportParams{
+		RaidID:           payload.RaidID,
+		AttackerPlayerID: fleet.PlayerID,
+		DefenderPlayerID: port.PlayerID,
+		FleetID:          pgtype.Int4{Int32: fleet.ID, Valid: true},
+		PortID:           port.ID,
+		DefenseRating:    stats.DefenseRating,
+		Outcome:          string(report.Outcome),
+		Report:           data,
+		Looted:           cargoTotal(report.Captured),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record raid report: %w", err)
+	}
+
+	if !report.Attacker.Afloat() {
+		err = q.DeleteFleet(ctx, fleet.ID)
+		if err != nil {
+			return fmt.Errorf("failed to remove sunk fleet: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetPlayerRaids returns the most recent raids a player carried out or
+// suffered.
+func (s *Service) GetPlayerRaids(ctx context.Context, playerID int32) ([]Raid, error) {
+	rows, err := s.queries.GetPlayerRaidReports(ctx, playerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get raid reports: %w", err)
+	}
+
+	raids := make([]Raid, 0, len(rows))
+	for _, row := range rows {
+		raid, err := newRaid(row)
+		if err != nil {
+			return nil, err
+		}
+		raids = append(raids, raid)
+	}
+	return raids, nil
+}
+
+// GetRaid returns a raid report. Only the raider and the raided player can
+// read it.
+func (s *Service) GetRaid(ctx context.Context, playerID, raidID int32) (*Raid, error) {
+	row, err := s.queries.GetRaidReport(ctx, raidID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && row.AttackerPlayerID != playerID && row.DefenderPlayerID != playerID) {
+		return nil, ErrRaidNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get raid report: %w", err)
+	}
+
+	raid, err := newRaid(row)
+	if err != nil {
+		return nil, err
+	}
+	return &raid, nil
+}
@@ -0,0 +1,36 @@
+package fleet
+
+import (
+	"github.com/bradcypert/stserver/internal/db"
+	"github.com/bradcypert/stserver/internal/events"
+	"github.com/bradcypert/stserver/internal/island"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Service runs a player's ships: building them at a shipyard and, once
+// launched, everything they do at sea. Resources are always paid and
+// received through the island service so they land in the port's ledger.
+type Service struct {
+	queries *db.Queries
+	pool    *pgxpool.Pool
+	events  *events.Queue
+	islands *island.Service
+}
+
+func NewService(pool *pgxpool.Pool, queue *events.Queue, islandService *island.Service) *Service {
+	return &Service{
+		queries: db.New(pool),
+		pool:    pool,
+		events:  queue,
+		islands: islandService,
+	}
+}
+
+// RegisterEventHandlers registers the game event handlers that need the
+// fleet service.
+func RegisterEventHandlers(registry *events.Registry, s *Service) {
+	events.Register(registry, events.GameEventShipConstruct, s.HandleShipConstructEvent)
+	events.Register(registry, events.GameEventFleetArrival, s.HandleFleetArrivalEvent)
+	events.Register(registry, events.GameEventFleetEngagement, s.HandleFleetEngagementEvent)
+	events.Register(registry, events.GameEventPortRaid, s.HandlePortRaidEvent)
+}
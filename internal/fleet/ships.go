@@ -0,0 +1,176 @@
+package fleet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bradcypert/stserver/internal/db"
+	"github.com/bradcypert/stserver/internal/events"
+	"github.com/bradcypert/stserver/internal/island"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrShipClassNotFound = errors.New("ship class not found")
+	ErrShipyardTooSmall  = errors.New("shipyard level too low")
+	ErrShipNameRequired  = errors.New("ship name is required")
+)
+
+type ShipConstructPayload struct {
+	ShipID int32 `json:"ship_id"`
+}
+
+// ShipClass is a ship design with what it costs to build.
+type ShipClass struct {
+	db.ShipClass
+	Cost island.Resources `json:"cost"`
+}
+
+// BuildShipRequest asks for a new ship of ShipClass to be laid down at the
+// player's port.
+type BuildShipRequest struct {
+	PlayerID  int32  `json:"player_id"`
+	PortID    int32  `json:"port_id"`
+	ShipClass string `json:"ship_class"`
+	Name      string `json:"name"`
+}
+
+func classCost(costs []db.ShipClassCost, class string) island.Resources {
+	cost := island.Resources{}
+	for _, c := range costs {
+		if c.ShipClass == class {
+			cost[c.ResourceType] = c.Amount
+		}
+	}
+	return cost
+}
+
+// GetShipClasses returns every ship class with its cost.
+func (s *Service) GetShipClasses(ctx context.Context) ([]ShipClass, error) {
+	classes, err := s.queries.GetAllShipClasses(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ship classes: %w", err)
+	}
+
+	costs, err := s.queries.GetAllShipClassCosts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ship class costs: %w", err)
+	}
+
+	shipClasses := make([]ShipClass, 0, len(classes))
+	for _, class := range classes {
+		shipClasses = append(shipClasses, ShipClass{
+			ShipClass: class,
+			Cost:      classCost(costs, class.Name),
+		})
+	}
+	return shipClasses, nil
+}
+
+// BuildShip pays for a ship and lays it down at the player's port. It needs
+// a shipyard of at least the class's required level, and is launched by a
+// scheduled ship construction event once its build time is up.
+func (s *Service) BuildShip(ctx context.Context, req BuildShipRequest) (*db.Ship, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, ErrShipNameRequired
+	}
+
+	class, err := s.queries.GetShipClass(ctx, req.ShipClass)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrShipClassNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ship class: %w", err)
+	}
+
+	costs, err := s.queries.GetShipClassCosts(ctx, class.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ship class costs: %w", err)
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.queries.WithTx(tx)
+	now := time.Now()
+	completesAt := now.Add(time.Duration(class.BuildTimeSeconds) * time.Second)
+
+	ship, err := q.CreateShip(ctx, db.CreateShipParams{
+		PlayerID:    req.PlayerID,
+		PortID:      pgtype.Int4{Int32: req.PortID, Valid: true},
+		ShipClass:   class.Name,
+		Name:        name,
+		Hull:        class.Hull,
+		Crew:        class.Crew,
+		CompletesAt: pgtype.Timestamptz{Time: completesAt, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ship: %w", err)
+	}
+
+	// Spending takes the port lock, so the shipyard can't be demolished
+	// between the check below and the commit
+	err = s.islands.Spend(ctx, tx, req.PortID, classCost(costs, class.Name), island.LedgerShipConstruction, island.LedgerReference(ship.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	shipyardLevel, err := q.GetPortShipyardLevel(ctx, req.PortID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shipyard level: %w", err)
+	}
+	if shipyardLevel < class.RequiredShipyardLevel {
+		return nil, fmt.Errorf("%w: a %s needs a level %d shipyard", ErrShipyardTooSmall, class.Name, class.RequiredShipyardLevel)
+	}
+
+	event, err := events.NewEvent(events.GameEventShipConstruct, ShipConstructPayload{ShipID: ship.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	// Scheduled before committing so a ship is never left on the slipway.
+	// The handler ignores ships that don't exist or are already launched.
+	err = s.events.Schedule(ctx, event, completesAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to schedule ship construction: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ship, nil
+}
+
+// HandleShipConstructEvent launches a finished ship, leaving it docked at
+// the port it was built in. It is safe to run more than once for the same
+// ship.
+func (s *Service) HandleShipConstructEvent(ctx context.Context, payload ShipConstructPayload) error {
+	_, err := s.queries.CompleteShipConstruction(ctx, payload.ShipID)
+	if err != nil {
+		return fmt.Errorf("failed to complete ship construction: %w", err)
+	}
+	return nil
+}
+
+// GetPlayerShips returns all of a player's ships, including those still
+// being built.
+func (s *Service) GetPlayerShips(ctx context.Context, playerID int32) ([]db.Ship, error) {
+	ships, err := s.queries.GetPlayerShips(ctx, playerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ships: %w", err)
+	}
+	if ships == nil {
+		ships = []db.Ship{}
+	}
+	return ships, nil
+}
@@ -2,98 +2,187 @@ package internal
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 
 	"github.com/bradcypert/stserver/internal/events"
 	"github.com/bradcypert/stserver/internal/island"
+	"github.com/bradcypert/stserver/internal/market"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/redis/go-redis/v9"
 )
 
 type GameEngine struct {
 	logger        *slog.Logger
-	redis         *redis.Client
+	queue         *events.Queue
 	pool          *pgxpool.Pool
+	registry      *events.Registry
 	islandService *island.Service
+	marketService *market.Service
 }
 
-func NewGameEngine(logger *slog.Logger, redis *redis.Client, pool *pgxpool.Pool) GameEngine {
+// npcPriceInterval is how often NPC market prices are recalculated.
+const npcPriceInterval = time.Minute
+
+func NewGameEngine(logger *slog.Logger, queue *events.Queue, pool *pgxpool.Pool, registry *events.Registry, islandService *island.Service, marketService *market.Service) GameEngine {
 	return GameEngine{
 		logger:        logger,
-		redis:         redis,
+		queue:         queue,
 		pool:          pool,
-		islandService: island.NewService(pool),
+		registry:      registry,
+		islandService: islandService,
+		marketService: marketService,
 	}
 }
 
 func (engine *GameEngine) StartTickEngine(ctx context.Context) {
 	engine.logger.Debug("Starting Ticker Engine")
+
+	// Events claimed by a process that died before acking them become due
+	// again once their lease runs out.
+	engine.recoverExpiredEvents(ctx)
+
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
 
+	priceTicker := time.NewTicker(npcPriceInterval)
+	defer priceTicker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
 			fmt.Println("Tick engine stopping...")
 			return
 		case <-ticker.C:
+			engine.recoverExpiredEvents(ctx)
 			engine.processDueEvents(ctx)
-			engine.processResourceGeneration(ctx)
 			engine.processCompletedConstructions(ctx)
+		case <-priceTicker.C:
+			engine.recalculateNpcPrices(ctx)
 		}
 	}
 }
 
+func (engine *GameEngine) recoverExpiredEvents(ctx context.Context) {
+	recovered, err := engine.queue.RecoverExpired(ctx, time.Now())
+	if err != nil {
+		engine.logger.Error("Error recovering expired events", slog.String("error", err.Error()))
+		return
+	}
+	if recovered > 0 {
+		engine.logger.Info("Recovered expired events", slog.Int("Count", recovered))
+	}
+}
+
 func (engine *GameEngine) processDueEvents(ctx context.Context) {
 	engine.logger.Debug("Processing Due Events")
-	now := float64(time.Now().Unix())
-
-	e, err := engine.redis.ZRangeByScore(ctx, "game_events", &redis.ZRangeBy{
-		Min:    "0",
-		Max:    fmt.Sprintf("%.0f", now),
-		Offset: 0,
-		Count:  250,
-	}).Result()
-
-	engine.logger.Debug("Collected events", slog.Int("Count", len(e)))
 
+	deliveries, err := engine.queue.Claim(ctx, time.Now(), 250)
 	if err != nil {
-		fmt.Println("Tick error:", err)
+		engine.logger.Error("Tick error", slog.String("error", err.Error()))
 		return
 	}
 
-	for _, raw := range e {
-		var event events.GameEvent
-		if err := json.Unmarshal([]byte(raw), &event); err != nil {
-			fmt.Println("Unmarshal error:", err)
+	engine.logger.Debug("Collected events", slog.Int("Count", len(deliveries)))
+
+	for _, delivery := range deliveries {
+		// Checked first so a duplicate of an event that was already handled is
+		// dropped, even if its copy can no longer be decoded
+		processed, err := engine.queue.IsProcessed(ctx, delivery.ID)
+		if err != nil {
+			engine.logger.Error("Error checking event", slog.String("error", err.Error()))
+			continue
+		}
+		if processed {
+			engine.logger.Debug("Skipping duplicate event", slog.String("Event ID", delivery.ID))
+			engine.ack(ctx, delivery.ID)
 			continue
 		}
 
-		engine.handleEvent(ctx, event)
-		engine.redis.ZRem(ctx, "game_events", raw)
+		if delivery.Err != nil {
+			engine.deadLetter(ctx, delivery, delivery.Err.Error())
+			continue
+		}
+
+		// Earlier events in the batch may have used up part of this one's
+		// lease, so renew it before starting
+		claimed, err := engine.queue.Extend(ctx, delivery.ID, time.Now())
+		if err != nil {
+			engine.logger.Error("Error extending event lease", slog.String("error", err.Error()))
+			continue
+		}
+		if !claimed {
+			engine.logger.Debug("Skipping event whose lease ran out", slog.String("Event ID", delivery.ID))
+			continue
+		}
+
+		release := engine.queue.Hold(ctx, delivery.ID)
+		err = engine.handleEvent(ctx, delivery.Event)
+		release()
+
+		switch {
+		case errors.Is(err, events.ErrUnknownEventType):
+			// Retrying will not help until a handler is registered.
+			engine.deadLetter(ctx, delivery, err.Error())
+		case err != nil:
+			engine.retry(ctx, delivery.Event, err)
+		default:
+			engine.ack(ctx, delivery.ID)
+		}
 	}
 }
 
-func (engine *GameEngine) handleEvent(_ context.Context, event events.GameEvent) {
-	if (event.EventType == events.GameEventPortBuilding) && (event.BuildingType != "") {
-		events.HandleBuildEvent(context.Background(), event, engine.pool)
+func (engine *GameEngine) ack(ctx context.Context, id string) {
+	if err := engine.queue.Ack(ctx, id); err != nil {
+		engine.logger.Error("Error acking event", slog.String("error", err.Error()))
 	}
-	engine.logger.Debug("Handling Event!",
-		slog.Int("Event Type", int(event.EventType)),
-		slog.Int("Port ID", int(event.PortID)),
-		slog.String("Building Type", event.BuildingType),
+}
+
+func (engine *GameEngine) retry(ctx context.Context, event events.GameEvent, cause error) {
+	dead, err := engine.queue.Retry(ctx, event, cause, time.Now())
+	if err != nil {
+		// The event stays claimed and is redelivered once its lease expires.
+		engine.logger.Error("Error rescheduling event", slog.String("error", err.Error()))
+		return
+	}
+	if dead {
+		engine.logger.Warn("Event moved to dead letter store",
+			slog.String("Event ID", event.ID),
+			slog.String("Event Type", event.EventType.String()),
+		)
+	}
+}
+
+func (engine *GameEngine) deadLetter(ctx context.Context, delivery events.Delivery, reason string) {
+	engine.logger.Error("Dead lettering event",
+		slog.String("Event ID", delivery.ID),
+		slog.String("reason", reason),
 	)
+	if err := engine.queue.DeadLetter(ctx, delivery.ID, delivery.Raw, reason, time.Now()); err != nil {
+		engine.logger.Error("Error dead lettering event", slog.String("error", err.Error()))
+	}
 }
 
-func (engine *GameEngine) processResourceGeneration(ctx context.Context) {
-	engine.logger.Debug("Processing Resource Generation")
-	err := engine.islandService.ProcessResourceGeneration(ctx)
+func (engine *GameEngine) handleEvent(ctx context.Context, event events.GameEvent) error {
+	engine.logger.Debug("Handling Event!",
+		slog.String("Event ID", event.ID),
+		slog.String("Event Type", event.EventType.String()),
+		slog.Int("Attempt", event.Attempts+1),
+		slog.String("Payload", string(event.Payload)),
+	)
+
+	err := engine.registry.Dispatch(ctx, event)
 	if err != nil {
-		engine.logger.Error("Error processing resource generation", slog.String("error", err.Error()))
+		engine.logger.Error("Error handling event",
+			slog.String("Event ID", event.ID),
+			slog.String("Event Type", event.EventType.String()),
+			slog.String("error", err.Error()),
+		)
+		return err
 	}
+
+	return nil
 }
 
 func (engine *GameEngine) processCompletedConstructions(ctx context.Context) {
@@ -103,3 +192,11 @@ func (engine *GameEngine) processCompletedConstructions(ctx context.Context) {
 		engine.logger.Error("Error processing completed constructions", slog.String("error", err.Error()))
 	}
 }
+
+func (engine *GameEngine) recalculateNpcPrices(ctx context.Context) {
+	engine.logger.Debug("Recalculating NPC Prices")
+	err := engine.marketService.RecalculateNpcPrices(ctx, time.Now())
+	if err != nil {
+		engine.logger.Error("Error recalculating NPC prices", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bradcypert/stserver/internal/auth"
+	"github.com/bradcypert/stserver/internal/db"
+	"github.com/bradcypert/stserver/internal/events"
+	"github.com/bradcypert/stserver/internal/island"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AdminHandler struct {
+	queries       *db.Queries
+	queue         *events.Queue
+	islandService *island.Service
+}
+
+func NewAdminHandler(pool *pgxpool.Pool, queue *events.Queue, islandService *island.Service) *AdminHandler {
+	return &AdminHandler{
+		queries:       db.New(pool),
+		queue:         queue,
+		islandService: islandService,
+	}
+}
+
+type deadEventsResponse struct {
+	Events []events.DeadEvent `json:"events"`
+	Total  int64              `json:"total"`
+}
+
+// RequireAdmin only lets users flagged as admins through. It expects to run
+// behind auth.Service.RequireAuth.
+func (h *AdminHandler) RequireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := auth.GetUserIDFromContext(r.Context())
+		if !ok {
+			http.Error(w, "user not authenticated", http.StatusUnauthorized)
+			return
+		}
+
+		user, err := h.queries.GetUserByID(r.Context(), userID)
+		if err != nil {
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		}
+
+		if !user.IsAdmin {
+			http.Error(w, "admin access required", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
+
+func (h *AdminHandler) ListDeadEvents(w http.ResponseWriter, r *http.Request) {
+	limit := int64(50)
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || parsed <= 0 || parsed > 500 {
+			http.Error(w, "limit must be between 1 and 500", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	offset := int64(0)
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		parsed, err := strconv.ParseInt(offsetStr, 10, 64)
+		if err != nil || parsed < 0 {
+			http.Error(w, "invalid offset", http.StatusBadRequest)
+			return
+		}
+		offset = parsed
+	}
+
+	deadEvents, total, err := h.queue.ListDead(r.Context(), offset, limit)
+	if err != nil {
+		http.Error(w, "failed to list dead events: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(deadEventsResponse{
+		Events: deadEvents,
+		Total:  total,
+	})
+}
+
+func (h *AdminHandler) GetDeadEvent(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "event ID is required", http.StatusBadRequest)
+		return
+	}
+
+	deadEvent, err := h.queue.GetDead(r.Context(), id)
+	if errors.Is(err, events.ErrDeadEventNotFound) {
+		http.Error(w, "dead event not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to get dead event: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(deadEvent)
+}
+
+func (h *AdminHandler) RequeueDeadEvent(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "event ID is required", http.StatusBadRequest)
+		return
+	}
+
+	err := h.queue.RequeueDead(r.Context(), id, time.Now())
+	if errors.Is(err, events.ErrDeadEventNotFound) {
+		http.Error(w, "dead event not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to requeue dead event: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Event requeued"})
+}
+
+func (h *AdminHandler) DiscardDeadEvent(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "event ID is required", http.StatusBadRequest)
+		return
+	}
+
+	err := h.queue.DiscardDead(r.Context(), id)
+	if errors.Is(err, events.ErrDeadEventNotFound) {
+		http.Error(w, "dead event not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to discard dead event: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Event discarded"})
+}
+
+type grantResearchRequest struct {
+	ResearchType string `json:"research_type"`
+}
+
+func (h *AdminHandler) GrantResearch(w http.ResponseWriter, r *http.Request) {
+	playerID, err := strconv.ParseInt(r.PathValue("player_id"), 10, 32)
+	if err != nil {
+		http.Error(w, "invalid player ID", http.StatusBadRequest)
+		return
+	}
+
+	var req grantResearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if req.ResearchType == "" {
+		http.Error(w, "research type is required", http.StatusBadRequest)
+		return
+	}
+
+	err = h.queries.GrantPlayerResearch(r.Context(), db.GrantPlayerResearchParams{
+		PlayerID:     int32(playerID),
+		ResearchType: req.ResearchType,
+	})
+	if err != nil {
+		http.Error(w, "failed to grant research: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	research, err := h.queries.GetPlayerResearch(r.Context(), int32(playerID))
+	if err != nil {
+		http.Error(w, "failed to get research: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(research)
+}
+
+type grantResourcesRequest struct {
+	Resources island.Resources `json:"resources"`
+}
+
+// GrantResources adds resources to a port, recorded in its ledger as an
+// admin grant referencing the admin who made it.
+func (h *AdminHandler) GrantResources(w http.ResponseWriter, r *http.Request) {
+	portID, err := strconv.ParseInt(r.PathValue("port_id"), 10, 32)
+	if err != nil {
+		http.Error(w, "invalid port ID", http.StatusBadRequest)
+		return
+	}
+
+	var req grantResourcesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Resources) == 0 {
+		http.Error(w, "resources are required", http.StatusBadRequest)
+		return
+	}
+
+	adminID, _ := auth.GetUserIDFromContext(r.Context())
+	err = h.islandService.Grant(r.Context(), int32(portID), req.Resources, island.LedgerAdminGrant, island.LedgerReference(adminID))
+	if err != nil {
+		http.Error(w, "failed to grant resources: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Resources granted"})
+}
@@ -9,19 +9,22 @@ import (
 
 	"github.com/bradcypert/stserver/internal/auth"
 	"github.com/bradcypert/stserver/internal/db"
+	"github.com/bradcypert/stserver/internal/island"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type AuthHandler struct {
-	queries     *db.Queries
-	authService *auth.Service
+	queries       *db.Queries
+	authService   *auth.Service
+	islandService *island.Service
 }
 
-func NewAuthHandler(pool *pgxpool.Pool, authService *auth.Service) *AuthHandler {
+func NewAuthHandler(pool *pgxpool.Pool, authService *auth.Service, islandService *island.Service) *AuthHandler {
 	return &AuthHandler{
-		queries:     db.New(pool),
-		authService: authService,
+		queries:       db.New(pool),
+		authService:   authService,
+		islandService: islandService,
 	}
 }
 
@@ -130,8 +133,8 @@ func (h *AuthHandler) Signup(w http.ResponseWriter, r *http.Request) {
 
 	// Link player to user
 	err = h.queries.UpdatePlayerUserID(r.Context(), db.UpdatePlayerUserIDParams{
-		UserID:   pgtype.Int4{Int32: user.ID, Valid: true},
-		PlayerID: player.ID,
+		UserID: pgtype.Int4{Int32: user.ID, Valid: true},
+		ID:     player.ID,
 	})
 	if err != nil {
 		http.Error(w, "failed to link player to user: "+err.Error(), http.StatusInternalServerError)
@@ -139,7 +142,7 @@ func (h *AuthHandler) Signup(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create player's starting island
-	island, err := h.queries.CreatePlayerIsland(r.Context(), db.CreatePlayerIslandParams{
+	playerIsland, err := h.queries.CreatePlayerIsland(r.Context(), db.CreatePlayerIslandParams{
 		PlayerID: player.ID,
 		Name:     req.DisplayName + "'s Island",
 		X:        rand.Int31n(1000), // Random position on game map
@@ -151,21 +154,20 @@ func (h *AuthHandler) Signup(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Initialize starting resources for the island
-	err = h.queries.InitializePortResources(r.Context(), island.ID)
+	err = h.queries.InitializePortResources(r.Context(), playerIsland.ID)
 	if err != nil {
 		http.Error(w, "failed to initialize island resources: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	// Add starting resources (wood, iron, gold, grain as defined in migration)
-	err = h.queries.AddResourcesToPort(r.Context(), db.AddResourcesToPortParams{
-		PortID: island.ID,
-		Wood:   100,
-		Iron:   20,
-		Gold:   50,
-		Grain:  25,
+	err = h.islandService.Grant(r.Context(), playerIsland.ID, island.Resources{
+		"wood":  100,
+		"iron":  20,
+		"gold":  50,
+		"grain": 25,
 		// Other resources start at 0
-	})
+	}, island.LedgerStartingResources, "")
 	if err != nil {
 		http.Error(w, "failed to add starting resources: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -31,6 +31,17 @@ type joinFactionResponse struct {
 	FactionName string `json:"faction_name"`
 }
 
+type factionTreasuryResponse struct {
+	FactionID   int32  `json:"faction_id"`
+	FactionName string `json:"faction_name"`
+	Treasury    int64  `json:"treasury"`
+}
+
+type factionRelationsResponse struct {
+	Stances   []db.FactionStance   `json:"stances"`
+	Relations []db.FactionRelation `json:"relations"`
+}
+
 type playerWithFactionResponse struct {
 	Player      db.Player `json:"player"`
 	FactionName string    `json:"faction_name"`
@@ -107,8 +118,8 @@ func (h *FactionHandler) JoinFaction(w http.ResponseWriter, r *http.Request) {
 
 	// Update player's faction
 	err = h.queries.UpdatePlayerFaction(r.Context(), db.UpdatePlayerFactionParams{
-		Faction:  req.FactionID,
-		PlayerID: player.ID,
+		Faction: req.FactionID,
+		ID:      player.ID,
 	})
 	if err != nil {
 		http.Error(w, "failed to update faction: "+err.Error(), http.StatusInternalServerError)
@@ -158,4 +169,45 @@ func (h *FactionHandler) GetPlayerFaction(w http.ResponseWriter, r *http.Request
 		Player:      player,
 		FactionName: faction.Name,
 	})
-}
\ No newline at end of file
+}
+
+func (h *FactionHandler) GetFactionTreasury(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 32)
+	if err != nil {
+		http.Error(w, "invalid faction ID", http.StatusBadRequest)
+		return
+	}
+
+	faction, err := h.queries.GetFactionByID(r.Context(), int32(id))
+	if err != nil {
+		http.Error(w, "faction not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(factionTreasuryResponse{
+		FactionID:   faction.ID,
+		FactionName: faction.Name,
+		Treasury:    faction.Treasury,
+	})
+}
+
+func (h *FactionHandler) GetFactionRelations(w http.ResponseWriter, r *http.Request) {
+	stances, err := h.queries.GetFactionStances(r.Context())
+	if err != nil {
+		http.Error(w, "failed to get faction stances: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	relations, err := h.queries.GetFactionRelations(r.Context())
+	if err != nil {
+		http.Error(w, "failed to get faction relations: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(factionRelationsResponse{
+		Stances:   stances,
+		Relations: relations,
+	})
+}
@@ -0,0 +1,438 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bradcypert/stserver/internal/db"
+	"github.com/bradcypert/stserver/internal/fleet"
+	"github.com/bradcypert/stserver/internal/island"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type FleetHandler struct {
+	queries      *db.Queries
+	fleetService *fleet.Service
+}
+
+func NewFleetHandler(pool *pgxpool.Pool, fleetService *fleet.Service) *FleetHandler {
+	return &FleetHandler{
+		queries:      db.New(pool),
+		fleetService: fleetService,
+	}
+}
+
+type buildShipRequest struct {
+	ShipClass string `json:"ship_class"`
+	Name      string `json:"name"`
+}
+
+func (h *FleetHandler) GetShipClasses(w http.ResponseWriter, r *http.Request) {
+	classes, err := h.fleetService.GetShipClasses(r.Context())
+	if err != nil {
+		http.Error(w, "failed to get ship classes: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(classes)
+}
+
+func (h *FleetHandler) BuildShip(w http.ResponseWriter, r *http.Request) {
+	var req buildShipRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	port, ok := playerPort(h.queries, w, r)
+	if !ok {
+		return
+	}
+
+	ship, err := h.fleetService.BuildShip(r.Context(), fleet.BuildShipRequest{
+		PlayerID:  port.PlayerID,
+		PortID:    port.ID,
+		ShipClass: req.ShipClass,
+		Name:      req.Name,
+	})
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, fleet.ErrShipClassNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, fleet.ErrShipyardTooSmall), errors.Is(err, island.ErrInsufficientResources):
+			status = http.StatusConflict
+		}
+		http.Error(w, "failed to build ship: "+err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ship)
+}
+
+func (h *FleetHandler) GetPlayerShips(w http.ResponseWriter, r *http.Request) {
+	port, ok := playerPort(h.queries, w, r)
+	if !ok {
+		return
+	}
+
+	ships, err := h.fleetService.GetPlayerShips(r.Context(), port.PlayerID)
+	if err != nil {
+		http.Error(w, "failed to get ships: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ships)
+}
+
+type createFleetRequest struct {
+	Name    string  `json:"name"`
+	ShipIDs []int32 `json:"ship_ids"`
+	PortID  *int32  `json:"port_id"`
+}
+
+// fleetTime reads the optional "at" query parameter, so clients can ask
+// where fleets will be at a given instant. It defaults to now.
+func fleetTime(w http.ResponseWriter, r *http.Request) (time.Time, bool) {
+	atStr := r.URL.Query().Get("at")
+	if atStr == "" {
+		return time.Now(), true
+	}
+
+	at, err := time.Parse(time.RFC3339, atStr)
+	if err != nil {
+		http.Error(w, "at must be an RFC 3339 timestamp", http.StatusBadRequest)
+		return time.Time{}, false
+	}
+	return at, true
+}
+
+func fleetID(w http.ResponseWriter, r *http.Request) (int32, bool) {
+	id, err := strconv.ParseInt(r.PathValue("fleet_id"), 10, 32)
+	if err != nil {
+		http.Error(w, "invalid fleet ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return int32(id), true
+}
+
+func fleetErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, fleet.ErrFleetNotFound), errors.Is(err, fleet.ErrBattleNotFound), errors.Is(err, fleet.ErrRaidNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, fleet.ErrShipUnavailable), errors.Is(err, fleet.ErrFleetNotDocked), errors.Is(err, fleet.ErrFleetAlreadyArrived),
+		errors.Is(err, fleet.ErrFleetHasCargo), errors.Is(err, fleet.ErrCargoHoldFull), errors.Is(err, fleet.ErrInsufficientCargo),
+		errors.Is(err, island.ErrInsufficientResources), errors.Is(err, fleet.ErrPortProtected), errors.Is(err, fleet.ErrRaidCooldown),
+		errors.Is(err, fleet.ErrRaidLimitReached):
+		return http.StatusConflict
+	case errors.Is(err, fleet.ErrNotPlayerPort):
+		return http.StatusForbidden
+	case errors.Is(err, fleet.ErrFleetNameRequired), errors.Is(err, fleet.ErrFleetEmpty), errors.Is(err, fleet.ErrInvalidDestination),
+		errors.Is(err, fleet.ErrCargoEmpty), errors.Is(err, fleet.ErrInvalidCargoAmount), errors.Is(err, fleet.ErrInvalidRaidTarget):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (h *FleetHandler) GetPlayerFleets(w http.ResponseWriter, r *http.Request) {
+	at, ok := fleetTime(w, r)
+	if !ok {
+		return
+	}
+
+	port, ok := playerPort(h.queries, w, r)
+	if !ok {
+		return
+	}
+
+	fleets, err := h.fleetService.GetPlayerFleets(r.Context(), port.PlayerID, at)
+	if err != nil {
+		http.Error(w, "failed to get fleets: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(fleets)
+}
+
+func (h *FleetHandler) CreateFleet(w http.ResponseWriter, r *http.Request) {
+	var req createFleetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	port, ok := playerPort(h.queries, w, r)
+	if !ok {
+		return
+	}
+
+	// Ships that sailed to another port can be grouped there
+	portID := port.ID
+	if req.PortID != nil {
+		portID = *req.PortID
+	}
+
+	created, err := h.fleetService.CreateFleet(r.Context(), fleet.CreateFleetRequest{
+		PlayerID: port.PlayerID,
+		PortID:   portID,
+		Name:     req.Name,
+		ShipIDs:  req.ShipIDs,
+	})
+	if err != nil {
+		http.Error(w, "failed to create fleet: "+err.Error(), fleetErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+func (h *FleetHandler) GetFleet(w http.ResponseWriter, r *http.Request) {
+	id, ok := fleetID(w, r)
+	if !ok {
+		return
+	}
+
+	at, ok := fleetTime(w, r)
+	if !ok {
+		return
+	}
+
+	port, ok := playerPort(h.queries, w, r)
+	if !ok {
+		return
+	}
+
+	f, err := h.fleetService.GetFleet(r.Context(), port.PlayerID, id, at)
+	if err != nil {
+		http.Error(w, "failed to get fleet: "+err.Error(), fleetErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(f)
+}
+
+func (h *FleetHandler) DispatchFleet(w http.ResponseWriter, r *http.Request) {
+	id, ok := fleetID(w, r)
+	if !ok {
+		return
+	}
+
+	var req fleet.DispatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	port, ok := playerPort(h.queries, w, r)
+	if !ok {
+		return
+	}
+
+	dispatched, err := h.fleetService.DispatchFleet(r.Context(), port.PlayerID, id, req)
+	if err != nil {
+		http.Error(w, "failed to dispatch fleet: "+err.Error(), fleetErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(dispatched)
+}
+
+func (h *FleetHandler) DisbandFleet(w http.ResponseWriter, r *http.Request) {
+	id, ok := fleetID(w, r)
+	if !ok {
+		return
+	}
+
+	port, ok := playerPort(h.queries, w, r)
+	if !ok {
+		return
+	}
+
+	err := h.fleetService.DisbandFleet(r.Context(), port.PlayerID, id)
+	if err != nil {
+		http.Error(w, "failed to disband fleet: "+err.Error(), fleetErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type cargoRequest struct {
+	Resources island.Resources `json:"resources"`
+}
+
+func (h *FleetHandler) LoadCargo(w http.ResponseWriter, r *http.Request) {
+	id, ok := fleetID(w, r)
+	if !ok {
+		return
+	}
+
+	var req cargoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	port, ok := playerPort(h.queries, w, r)
+	if !ok {
+		return
+	}
+
+	loaded, err := h.fleetService.LoadCargo(r.Context(), port.PlayerID, id, req.Resources)
+	if err != nil {
+		http.Error(w, "failed to load cargo: "+err.Error(), fleetErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(loaded)
+}
+
+func (h *FleetHandler) UnloadCargo(w http.ResponseWriter, r *http.Request) {
+	id, ok := fleetID(w, r)
+	if !ok {
+		return
+	}
+
+	var req cargoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	port, ok := playerPort(h.queries, w, r)
+	if !ok {
+		return
+	}
+
+	unloaded, err := h.fleetService.UnloadCargo(r.Context(), port.PlayerID, id, req.Resources)
+	if err != nil {
+		http.Error(w, "failed to unload cargo: "+err.Error(), fleetErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(unloaded)
+}
+
+func (h *FleetHandler) GetFleetsNear(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	x, errX := strconv.ParseFloat(query.Get("x"), 64)
+	y, errY := strconv.ParseFloat(query.Get("y"), 64)
+	if errX != nil || errY != nil {
+		http.Error(w, "x and y are required", http.StatusBadRequest)
+		return
+	}
+
+	radius := 10.0
+	if radiusStr := query.Get("radius"); radiusStr != "" {
+		parsed, err := strconv.ParseFloat(radiusStr, 64)
+		if err != nil || parsed <= 0 || parsed > 100 {
+			http.Error(w, "radius must be between 0 and 100", http.StatusBadRequest)
+			return
+		}
+		radius = parsed
+	}
+
+	at, ok := fleetTime(w, r)
+	if !ok {
+		return
+	}
+
+	sightings, err := h.fleetService.GetFleetsNear(r.Context(), fleet.Position{X: x, Y: y}, radius, at)
+	if err != nil {
+		http.Error(w, "failed to get fleets: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(sightings)
+}
+
+func (h *FleetHandler) GetPlayerBattles(w http.ResponseWriter, r *http.Request) {
+	port, ok := playerPort(h.queries, w, r)
+	if !ok {
+		return
+	}
+
+	battles, err := h.fleetService.GetPlayerBattles(r.Context(), port.PlayerID)
+	if err != nil {
+		http.Error(w, "failed to get battles: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(battles)
+}
+
+func (h *FleetHandler) GetBattle(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("battle_id"), 10, 32)
+	if err != nil {
+		http.Error(w, "invalid battle ID", http.StatusBadRequest)
+		return
+	}
+
+	port, ok := playerPort(h.queries, w, r)
+	if !ok {
+		return
+	}
+
+	battle, err := h.fleetService.GetBattle(r.Context(), port.PlayerID, int32(id))
+	if err != nil {
+		http.Error(w, "failed to get battle: "+err.Error(), fleetErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(battle)
+}
+
+func (h *FleetHandler) GetPlayerRaids(w http.ResponseWriter, r *http.Request) {
+	port, ok := playerPort(h.queries, w, r)
+	if !ok {
+		return
+	}
+
+	raids, err := h.fleetService.GetPlayerRaids(r.Context(), port.PlayerID)
+	if err != nil {
+		http.Error(w, "failed to get raids: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(raids)
+}
+
+func (h *FleetHandler) GetRaid(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("raid_id"), 10, 32)
+	if err != nil {
+		http.Error(w, "invalid raid ID", http.StatusBadRequest)
+		return
+	}
+
+	port, ok := playerPort(h.queries, w, r)
+	if !ok {
+		return
+	}
+
+	raid, err := h.fleetService.GetRaid(r.Context(), port.PlayerID, int32(id))
+	if err != nil {
+		http.Error(w, "failed to get raid: "+err.Error(), fleetErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(raid)
+}
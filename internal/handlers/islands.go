@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/bradcypert/stserver/internal/auth"
 	"github.com/bradcypert/stserver/internal/db"
@@ -16,10 +18,10 @@ type IslandHandler struct {
 	islandService *island.Service
 }
 
-func NewIslandHandler(pool *pgxpool.Pool) *IslandHandler {
+func NewIslandHandler(pool *pgxpool.Pool, islandService *island.Service) *IslandHandler {
 	return &IslandHandler{
 		queries:       db.New(pool),
-		islandService: island.NewService(pool),
+		islandService: islandService,
 	}
 }
 
@@ -164,8 +166,8 @@ func (h *IslandHandler) ConstructBuilding(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// Construct building
-	building, err := h.islandService.ConstructBuilding(r.Context(), island.BuildingConstructionRequest{
+	// Queue construction
+	item, err := h.islandService.ConstructBuilding(r.Context(), island.BuildingConstructionRequest{
 		PortID:       port.ID,
 		BuildingType: req.BuildingType,
 	})
@@ -175,7 +177,7 @@ func (h *IslandHandler) ConstructBuilding(w http.ResponseWriter, r *http.Request
 	}
 
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(building)
+	json.NewEncoder(w).Encode(item)
 }
 
 func (h *IslandHandler) UpgradeBuilding(w http.ResponseWriter, r *http.Request) {
@@ -228,19 +230,164 @@ func (h *IslandHandler) UpgradeBuilding(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Upgrade building
-	err = h.islandService.UpgradeBuilding(r.Context(), int32(buildingID))
+	// Queue the upgrade
+	item, err := h.islandService.UpgradeBuilding(r.Context(), int32(buildingID))
 	if err != nil {
 		http.Error(w, "failed to upgrade building: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"message": "Building upgrade started"})
+	json.NewEncoder(w).Encode(item)
+}
+
+// playerPort loads the authenticated player's island, writing the error
+// response itself when it can't.
+func playerPort(queries *db.Queries, w http.ResponseWriter, r *http.Request) (db.Port, bool) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "user not authenticated", http.StatusUnauthorized)
+		return db.Port{}, false
+	}
+
+	user, err := queries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "user not found", http.StatusNotFound)
+		return db.Port{}, false
+	}
+
+	player, err := queries.GetPlayerByEmail(r.Context(), user.Email)
+	if err != nil {
+		http.Error(w, "player not found", http.StatusNotFound)
+		return db.Port{}, false
+	}
+
+	port, err := queries.GetPortByPlayerId(r.Context(), player.ID)
+	if err != nil {
+		http.Error(w, "island not found", http.StatusNotFound)
+		return db.Port{}, false
+	}
+
+	return port, true
+}
+
+// authenticatedPort is playerPort for endpoints that also serve anonymous
+// callers: it reports whether there is a port rather than writing an error.
+func authenticatedPort(queries *db.Queries, r *http.Request) (db.Port, bool) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		return db.Port{}, false
+	}
+
+	user, err := queries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		return db.Port{}, false
+	}
+
+	player, err := queries.GetPlayerByEmail(r.Context(), user.Email)
+	if err != nil {
+		return db.Port{}, false
+	}
+
+	port, err := queries.GetPortByPlayerId(r.Context(), player.ID)
+	if err != nil {
+		return db.Port{}, false
+	}
+
+	return port, true
+}
+
+func queueItemID(w http.ResponseWriter, r *http.Request) (int32, bool) {
+	itemID, err := strconv.ParseInt(r.PathValue("item_id"), 10, 32)
+	if err != nil {
+		http.Error(w, "invalid queue item ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return int32(itemID), true
+}
+
+func queueErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, island.ErrQueueItemNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, island.ErrQueueItemStarted):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+type moveQueueItemRequest struct {
+	Position int32 `json:"position"`
+}
+
+func (h *IslandHandler) MoveQueuedConstruction(w http.ResponseWriter, r *http.Request) {
+	itemID, ok := queueItemID(w, r)
+	if !ok {
+		return
+	}
+
+	var req moveQueueItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if req.Position < 1 {
+		http.Error(w, "position must be at least 1", http.StatusBadRequest)
+		return
+	}
+
+	port, ok := playerPort(h.queries, w, r)
+	if !ok {
+		return
+	}
+
+	err := h.islandService.MoveQueuedConstruction(r.Context(), port.ID, itemID, req.Position)
+	if err != nil {
+		http.Error(w, "failed to move queue item: "+err.Error(), queueErrorStatus(err))
+		return
+	}
+
+	queue, err := h.islandService.GetConstructionQueue(r.Context(), port.ID)
+	if err != nil {
+		http.Error(w, "failed to get construction queue: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(queue)
+}
+
+func (h *IslandHandler) RemoveQueuedConstruction(w http.ResponseWriter, r *http.Request) {
+	itemID, ok := queueItemID(w, r)
+	if !ok {
+		return
+	}
+
+	port, ok := playerPort(h.queries, w, r)
+	if !ok {
+		return
+	}
+
+	err := h.islandService.RemoveQueuedConstruction(r.Context(), port.ID, itemID)
+	if err != nil {
+		http.Error(w, "failed to remove queue item: "+err.Error(), queueErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Queued construction removed and refunded"})
 }
 
 func (h *IslandHandler) GetBuildingTypes(w http.ResponseWriter, r *http.Request) {
-	buildingTypes, err := h.queries.GetAllBuildingTypes(r.Context())
+	// Signed in players also see which buildings their island has unlocked
+	var portID *int32
+	if port, ok := authenticatedPort(h.queries, r); ok {
+		portID = &port.ID
+	}
+
+	buildingTypes, err := h.islandService.GetBuildingTree(r.Context(), portID)
 	if err != nil {
 		http.Error(w, "failed to get building types: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -250,6 +397,32 @@ func (h *IslandHandler) GetBuildingTypes(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(buildingTypes)
 }
 
+func (h *IslandHandler) GetBuildingLevels(w http.ResponseWriter, r *http.Request) {
+	levels, err := h.islandService.GetBuildingLevels(r.Context(), r.PathValue("type"))
+	if errors.Is(err, island.ErrBuildingTypeNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to get building levels: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(levels)
+}
+
+func (h *IslandHandler) GetResourceTypes(w http.ResponseWriter, r *http.Request) {
+	resourceTypes, err := h.queries.GetAllResourceTypes(r.Context())
+	if err != nil {
+		http.Error(w, "failed to get resource types: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resourceTypes)
+}
+
 func (h *IslandHandler) GetBuildingProduction(w http.ResponseWriter, r *http.Request) {
 	buildingType := r.URL.Query().Get("type")
 	if buildingType == "" {
@@ -265,4 +438,138 @@ func (h *IslandHandler) GetBuildingProduction(w http.ResponseWriter, r *http.Req
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(production)
-}
\ No newline at end of file
+}
+
+func (h *IslandHandler) CancelConstruction(w http.ResponseWriter, r *http.Request) {
+	buildingID, err := strconv.ParseInt(r.PathValue("building_id"), 10, 32)
+	if err != nil {
+		http.Error(w, "invalid building ID", http.StatusBadRequest)
+		return
+	}
+
+	port, ok := playerPort(h.queries, w, r)
+	if !ok {
+		return
+	}
+
+	building, err := h.queries.GetBuilding(r.Context(), int32(buildingID))
+	if err != nil || building.PortID != port.ID {
+		http.Error(w, "building not found", http.StatusNotFound)
+		return
+	}
+
+	refund, err := h.islandService.CancelConstruction(r.Context(), building.ID)
+	if errors.Is(err, island.ErrNoConstruction) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to cancel construction: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"message":  "Construction cancelled",
+		"refunded": refund,
+	})
+}
+
+func (h *IslandHandler) DemolishBuilding(w http.ResponseWriter, r *http.Request) {
+	buildingID, err := strconv.ParseInt(r.PathValue("building_id"), 10, 32)
+	if err != nil {
+		http.Error(w, "invalid building ID", http.StatusBadRequest)
+		return
+	}
+
+	port, ok := playerPort(h.queries, w, r)
+	if !ok {
+		return
+	}
+
+	building, err := h.queries.GetBuilding(r.Context(), int32(buildingID))
+	if err != nil || building.PortID != port.ID {
+		http.Error(w, "building not found", http.StatusNotFound)
+		return
+	}
+
+	demolished, err := h.islandService.DemolishBuilding(r.Context(), building.ID)
+	if errors.Is(err, island.ErrBuildingUnderConstruction) || errors.Is(err, island.ErrBuildingDemolishing) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to demolish building: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(demolished)
+}
+
+type ledgerResponse struct {
+	Entries []db.ResourceLedger `json:"entries"`
+	Total   int64               `json:"total"`
+}
+
+func (h *IslandHandler) GetLedger(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := island.LedgerFilter{
+		ResourceType: query.Get("resource_type"),
+		Reason:       query.Get("reason"),
+		Limit:        50,
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.ParseInt(limitStr, 10, 32)
+		if err != nil || limit <= 0 || limit > 500 {
+			http.Error(w, "limit must be between 1 and 500", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = int32(limit)
+	}
+
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		offset, err := strconv.ParseInt(offsetStr, 10, 32)
+		if err != nil || offset < 0 {
+			http.Error(w, "invalid offset", http.StatusBadRequest)
+			return
+		}
+		filter.Offset = int32(offset)
+	}
+
+	if sinceStr := query.Get("since"); sinceStr != "" {
+		since, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			http.Error(w, "since must be an RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		filter.Since = since
+	}
+
+	if untilStr := query.Get("until"); untilStr != "" {
+		until, err := time.Parse(time.RFC3339, untilStr)
+		if err != nil {
+			http.Error(w, "until must be an RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		filter.Until = until
+	}
+
+	port, ok := playerPort(h.queries, w, r)
+	if !ok {
+		return
+	}
+
+	entries, total, err := h.islandService.GetLedger(r.Context(), port.ID, filter)
+	if err != nil {
+		http.Error(w, "failed to get ledger: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ledgerResponse{
+		Entries: entries,
+		Total:   total,
+	})
+}
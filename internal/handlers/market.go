@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bradcypert/stserver/internal/db"
+	"github.com/bradcypert/stserver/internal/island"
+	"github.com/bradcypert/stserver/internal/market"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type MarketHandler struct {
+	queries       *db.Queries
+	marketService *market.Service
+}
+
+func NewMarketHandler(pool *pgxpool.Pool, marketService *market.Service) *MarketHandler {
+	return &MarketHandler{
+		queries:       db.New(pool),
+		marketService: marketService,
+	}
+}
+
+type placeOrderRequest struct {
+	Side           string `json:"side"`
+	ResourceType   string `json:"resource_type"`
+	PricePerUnit   int32  `json:"price_per_unit"`
+	Quantity       int32  `json:"quantity"`
+	ExpiresInHours int32  `json:"expires_in_hours"`
+}
+
+func marketErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, market.ErrOrderNotFound), errors.Is(err, market.ErrUnknownResource), errors.Is(err, market.ErrNpcMarketClosed),
+		errors.Is(err, market.ErrTradingPostNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, market.ErrOrderNotOpen), errors.Is(err, market.ErrTooManyOpenOrders), errors.Is(err, island.ErrInsufficientResources):
+		return http.StatusConflict
+	case errors.Is(err, market.ErrTradeCenterRequired):
+		return http.StatusForbidden
+	case errors.Is(err, market.ErrInvalidOrder):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (h *MarketHandler) GetOrderBook(w http.ResponseWriter, r *http.Request) {
+	book, err := h.marketService.GetOrderBook(r.Context(), r.PathValue("resource_type"))
+	if err != nil {
+		http.Error(w, "failed to get order book: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(book)
+}
+
+func (h *MarketHandler) PlaceOrder(w http.ResponseWriter, r *http.Request) {
+	var req placeOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	port, ok := playerPort(h.queries, w, r)
+	if !ok {
+		return
+	}
+
+	placed, err := h.marketService.PlaceOrder(r.Context(), market.PlaceOrderRequest{
+		PlayerID:     port.PlayerID,
+		PortID:       port.ID,
+		Side:         req.Side,
+		ResourceType: req.ResourceType,
+		PricePerUnit: req.PricePerUnit,
+		Quantity:     req.Quantity,
+		ExpiresIn:    time.Duration(req.ExpiresInHours) * time.Hour,
+	})
+	if err != nil {
+		http.Error(w, "failed to place order: "+err.Error(), marketErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(placed)
+}
+
+func (h *MarketHandler) GetPlayerOrders(w http.ResponseWriter, r *http.Request) {
+	port, ok := playerPort(h.queries, w, r)
+	if !ok {
+		return
+	}
+
+	orders, err := h.marketService.GetPlayerOrders(r.Context(), port.PlayerID)
+	if err != nil {
+		http.Error(w, "failed to get orders: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(orders)
+}
+
+func (h *MarketHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.ParseInt(r.PathValue("order_id"), 10, 32)
+	if err != nil {
+		http.Error(w, "invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	port, ok := playerPort(h.queries, w, r)
+	if !ok {
+		return
+	}
+
+	order, err := h.marketService.CancelOrder(r.Context(), port.PlayerID, int32(orderID))
+	if err != nil {
+		http.Error(w, "failed to cancel order: "+err.Error(), marketErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *MarketHandler) GetNpcPrices(w http.ResponseWriter, r *http.Request) {
+	window := market.NpcPriceHistoryWindow
+	if hoursStr := r.URL.Query().Get("history_hours"); hoursStr != "" {
+		hours, err := strconv.ParseInt(hoursStr, 10, 32)
+		if err != nil || hours < 0 || time.Duration(hours)*time.Hour > market.NpcPriceHistoryWindow {
+			http.Error(w, "history_hours must be between 0 and 24", http.StatusBadRequest)
+			return
+		}
+		window = time.Duration(hours) * time.Hour
+	}
+
+	prices, err := h.marketService.GetNpcPrices(r.Context(), time.Now().Add(-window))
+	if err != nil {
+		http.Error(w, "failed to get prices: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(prices)
+}
+
+type npcTradeRequest struct {
+	PostID       int32  `json:"post_id"`
+	Side         string `json:"side"`
+	ResourceType string `json:"resource_type"`
+	Quantity     int32  `json:"quantity"`
+}
+
+func (h *MarketHandler) TradeWithNpcs(w http.ResponseWriter, r *http.Request) {
+	var req npcTradeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	port, ok := playerPort(h.queries, w, r)
+	if !ok {
+		return
+	}
+
+	trade, err := h.marketService.TradeWithNpcs(r.Context(), market.NpcTradeRequest{
+		PlayerID:     port.PlayerID,
+		PortID:       port.ID,
+		PostID:       req.PostID,
+		Side:         req.Side,
+		ResourceType: req.ResourceType,
+		Quantity:     req.Quantity,
+	})
+	if err != nil {
+		http.Error(w, "failed to trade: "+err.Error(), marketErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(trade)
+}
+
+func (h *MarketHandler) GetTradingPosts(w http.ResponseWriter, r *http.Request) {
+	// Signed in players also see the terms each post gives them
+	var playerID *int32
+	if port, ok := authenticatedPort(h.queries, r); ok {
+		playerID = &port.PlayerID
+	}
+
+	posts, err := h.marketService.GetTradingPosts(r.Context(), playerID)
+	if err != nil {
+		http.Error(w, "failed to get trading posts: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(posts)
+}
@@ -0,0 +1,176 @@
+package island
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bradcypert/stserver/internal/db"
+	"github.com/bradcypert/stserver/internal/events"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrBuildingUnderConstruction = errors.New("building is under construction")
+	ErrBuildingDemolishing       = errors.New("building is already being demolished")
+)
+
+type DemolishPayload struct {
+	BuildingID int32 `json:"building_id"`
+}
+
+// RegisterEventHandlers registers the game event handlers that need the
+// island service.
+func RegisterEventHandlers(registry *events.Registry, s *Service) {
+	events.Register(registry, events.GameEventBuildingDemolish, s.HandleDemolishEvent)
+}
+
+// DemolishBuilding takes a building out of production straight away and
+// schedules its removal. Salvage is paid out when the demolition finishes.
+func (s *Service) DemolishBuilding(ctx context.Context, buildingID int32) (*db.Building, error) {
+	building, err := s.queries.GetBuilding(ctx, buildingID)
+	if err != nil {
+		return nil, fmt.Errorf("building not found: %w", err)
+	}
+
+	buildingType, err := s.queries.GetBuildingTypeByName(ctx, building.Type)
+	if err != nil {
+		return nil, fmt.Errorf("invalid building type: %w", err)
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.queries.WithTx(tx)
+	now := time.Now()
+
+	err = s.settlePort(ctx, q, building.PortID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Re-read under the port lock in case a construction just started
+	building, err = q.GetBuilding(ctx, buildingID)
+	if err != nil {
+		return nil, fmt.Errorf("building not found: %w", err)
+	}
+
+	if building.DemolishAt.Valid {
+		return nil, ErrBuildingDemolishing
+	}
+
+	_, err = q.GetConstructionQueueItemForBuilding(ctx, pgtype.Int4{Int32: buildingID, Valid: true})
+	if err == nil || building.UnderConstruction {
+		return nil, ErrBuildingUnderConstruction
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to check construction queue: %w", err)
+	}
+
+	demolishTime := time.Duration(float64(buildingType.BaseBuildTime)*s.config.DemolishTimeShare) * time.Second
+	demolishAt := now.Add(demolishTime)
+
+	err = q.MarkBuildingDemolishing(ctx, db.MarkBuildingDemolishingParams{
+		ID:         buildingID,
+		DemolishAt: pgtype.Timestamptz{Time: demolishAt, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark building for demolition: %w", err)
+	}
+
+	// The building stops producing as soon as demolition starts
+	err = s.refreshProductionRates(ctx, q, building.PortID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update production rates: %w", err)
+	}
+
+	event, err := events.NewEvent(events.GameEventBuildingDemolish, DemolishPayload{BuildingID: buildingID})
+	if err != nil {
+		return nil, err
+	}
+
+	// Scheduled before committing so a demolition is never left without an
+	// event to finish it. The handler ignores buildings that aren't marked.
+	err = s.events.Schedule(ctx, event, demolishAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to schedule demolition: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	building.DemolishAt = pgtype.Timestamptz{Time: demolishAt, Valid: true}
+	return &building, nil
+}
+
+// HandleDemolishEvent removes a demolished building and pays out its
+// salvage. It is safe to run more than once for the same building.
+func (s *Service) HandleDemolishEvent(ctx context.Context, payload DemolishPayload) error {
+	building, err := s.queries.GetBuilding(ctx, payload.BuildingID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get building: %w", err)
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.queries.WithTx(tx)
+
+	_, err = q.LockPortResources(ctx, building.PortID)
+	if err != nil {
+		return fmt.Errorf("failed to lock resources: %w", err)
+	}
+
+	// Another delivery may have finished the demolition already
+	building, err = q.GetBuilding(ctx, payload.BuildingID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get building: %w", err)
+	}
+	if !building.DemolishAt.Valid {
+		return nil
+	}
+	demolishedAt := building.DemolishAt.Time
+
+	// A demolished warehouse keeps its capacity up to the moment it's gone
+	err = s.settlePort(ctx, q, building.PortID, demolishedAt)
+	if err != nil {
+		return err
+	}
+
+	investments, err := q.GetBuildingInvestments(ctx, building.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get building investment: %w", err)
+	}
+
+	err = q.DeleteBuilding(ctx, building.ID)
+	if err != nil {
+		return fmt.Errorf("failed to remove building: %w", err)
+	}
+
+	salvage := make(Resources, len(investments))
+	for _, investment := range investments {
+		salvage[investment.ResourceType] = int32(float64(investment.Amount) * s.config.DemolishSalvageShare)
+	}
+	err = credit(ctx, q, building.PortID, salvage, LedgerSalvage, LedgerReference(building.ID))
+	if err != nil {
+		return fmt.Errorf("failed to add salvage: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
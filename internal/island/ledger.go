@@ -0,0 +1,164 @@
+package island
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/bradcypert/stserver/internal/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// LedgerReason says why a port's resources changed.
+type LedgerReason string
+
+const (
+	LedgerProduction        LedgerReason = "production"
+	LedgerConstruction      LedgerReason = "construction"
+	LedgerUpgrade           LedgerReason = "upgrade"
+	LedgerRefund            LedgerReason = "refund"
+	LedgerSalvage           LedgerReason = "salvage"
+	LedgerTrade             LedgerReason = "trade"
+	LedgerAdminGrant        LedgerReason = "admin_grant"
+	LedgerStartingResources LedgerReason = "starting_resources"
+	LedgerShipConstruction  LedgerReason = "ship_construction"
+	LedgerCargoLoad         LedgerReason = "cargo_load"
+	LedgerCargoUnload       LedgerReason = "cargo_unload"
+	LedgerRaid              LedgerReason = "raid"
+)
+
+// LedgerReference formats a row id for a ledger entry's reference.
+func LedgerReference(id int32) string {
+	return strconv.FormatInt(int64(id), 10)
+}
+
+// recordLedger writes one ledger row per non-zero delta. q should be bound
+// to the transaction that makes the change.
+func recordLedger(ctx context.Context, q *db.Queries, portID int32, deltas Resources, reason LedgerReason, reference string) error {
+	resourceTypes := make([]string, 0, len(deltas))
+	for resourceType, delta := range deltas {
+		if delta != 0 {
+			resourceTypes = append(resourceTypes, resourceType)
+		}
+	}
+	slices.Sort(resourceTypes)
+
+	for _, resourceType := range resourceTypes {
+		err := q.InsertLedgerEntry(ctx, db.InsertLedgerEntryParams{
+			PortID:       portID,
+			ResourceType: resourceType,
+			Delta:        deltas[resourceType],
+			Reason:       string(reason),
+			ReferenceID:  pgtype.Text{String: reference, Valid: reference != ""},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record ledger entry: %w", err)
+		}
+	}
+	return nil
+}
+
+// credit adds amounts to a port's resources and records them in the ledger.
+func credit(ctx context.Context, q *db.Queries, portID int32, amounts Resources, reason LedgerReason, reference string) error {
+	for resourceType, amount := range amounts {
+		if amount == 0 {
+			continue
+		}
+
+		err := q.AddPortResource(ctx, db.AddPortResourceParams{
+			PortID:       portID,
+			ResourceType: resourceType,
+			Amount:       amount,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add %s: %w", resourceType, err)
+		}
+	}
+	return recordLedger(ctx, q, portID, amounts, reason, reference)
+}
+
+// Grant adds amounts to a port's resources outside of normal play, such as
+// starting resources or an admin correction.
+func (s *Service) Grant(ctx context.Context, portID int32, amounts Resources, reason LedgerReason, reference string) error {
+	resourceTypes, err := s.queries.GetAllResourceTypes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get resource types: %w", err)
+	}
+
+	for resourceType, amount := range amounts {
+		if !slices.ContainsFunc(resourceTypes, func(t db.ResourceType) bool { return t.Name == resourceType }) {
+			return fmt.Errorf("unknown resource type %q", resourceType)
+		}
+		if amount < 0 {
+			return fmt.Errorf("cannot grant a negative amount of %s", resourceType)
+		}
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.queries.WithTx(tx)
+
+	// Settle first so the grant doesn't count towards earlier production
+	err = s.settlePort(ctx, q, portID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	err = credit(ctx, q, portID, amounts, reason, reference)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// LedgerFilter narrows a ledger listing. Zero values match everything.
+type LedgerFilter struct {
+	ResourceType string
+	Reason       string
+	Since        time.Time
+	Until        time.Time
+	Limit        int32
+	Offset       int32
+}
+
+// GetLedger returns a page of the port's ledger, newest first, and the total
+// number of entries matching the filter.
+func (s *Service) GetLedger(ctx context.Context, portID int32, filter LedgerFilter) ([]db.ResourceLedger, int64, error) {
+	resourceType := pgtype.Text{String: filter.ResourceType, Valid: filter.ResourceType != ""}
+	reason := pgtype.Text{String: filter.Reason, Valid: filter.Reason != ""}
+	since := pgtype.Timestamptz{Time: filter.Since, Valid: !filter.Since.IsZero()}
+	until := pgtype.Timestamptz{Time: filter.Until, Valid: !filter.Until.IsZero()}
+
+	entries, err := s.queries.GetPortLedger(ctx, db.GetPortLedgerParams{
+		PortID:       portID,
+		ResourceType: resourceType,
+		Reason:       reason,
+		Since:        since,
+		Until:        until,
+		RowLimit:     filter.Limit,
+		RowOffset:    filter.Offset,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get ledger: %w", err)
+	}
+
+	total, err := s.queries.CountPortLedger(ctx, db.CountPortLedgerParams{
+		PortID:       portID,
+		ResourceType: resourceType,
+		Reason:       reason,
+		Since:        since,
+		Until:        until,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count ledger entries: %w", err)
+	}
+
+	return entries, total, nil
+}
@@ -0,0 +1,175 @@
+package island
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/bradcypert/stserver/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrBuildingTypeNotFound = errors.New("building type not found")
+
+// ProductionFlow is one resource a building produces, converts or consumes
+// as upkeep at a level.
+type ProductionFlow struct {
+	ResourceType string `json:"resource_type"`
+	Flow         string `json:"flow"`
+	PerHour      int32  `json:"per_hour"`
+}
+
+// BuildingLevel is what it takes to build or upgrade to a level, and what
+// the building produces and gives its island once it gets there. Duration
+// is before any build time reduction.
+type BuildingLevel struct {
+	Level           int32              `json:"level"`
+	Cost            Resources          `json:"cost"`
+	DurationSeconds int32              `json:"duration_seconds"`
+	Production      []ProductionFlow   `json:"production"`
+	Effects         map[string]float64 `json:"effects"`
+}
+
+// curveAmount scales base to level as base * level^exponent *
+// multiplier^(level-1). With both parameters at 1 it is base * level.
+func curveAmount(base int32, multiplier, exponent float64, level int32) int32 {
+	scaled := float64(base) * math.Pow(float64(level), exponent) * math.Pow(multiplier, float64(level-1))
+	return int32(math.Round(scaled))
+}
+
+// levelCosts resolves a building type's cost and build time at any level,
+// from its explicit level tables where they exist and its curves otherwise.
+type levelCosts struct {
+	buildingType db.BuildingType
+	curves       []db.BuildingCostCurve
+	costs        map[int32]Resources
+	times        map[int32]int32
+}
+
+func newLevelCosts(buildingType db.BuildingType, curves []db.BuildingCostCurve, costs []db.BuildingLevelCost, times []db.BuildingLevelTime) levelCosts {
+	l := levelCosts{
+		buildingType: buildingType,
+		costs:        make(map[int32]Resources),
+		times:        make(map[int32]int32),
+	}
+	for _, curve := range curves {
+		if curve.BuildingType == buildingType.TypeName {
+			l.curves = append(l.curves, curve)
+		}
+	}
+	for _, cost := range costs {
+		if cost.BuildingType != buildingType.TypeName {
+			continue
+		}
+		if l.costs[cost.Level] == nil {
+			l.costs[cost.Level] = Resources{}
+		}
+		l.costs[cost.Level][cost.ResourceType] = cost.Amount
+	}
+	for _, t := range times {
+		if t.BuildingType == buildingType.TypeName {
+			l.times[t.Level] = t.DurationSeconds
+		}
+	}
+	return l
+}
+
+func (l levelCosts) cost(level int32) Resources {
+	if cost, ok := l.costs[level]; ok {
+		return cost
+	}
+
+	cost := Resources{}
+	for _, curve := range l.curves {
+		if amount := curveAmount(curve.BaseAmount, curve.Multiplier, curve.Exponent, level); amount > 0 {
+			cost[curve.ResourceType] = amount
+		}
+	}
+	return cost
+}
+
+func (l levelCosts) duration(level int32) int32 {
+	if duration, ok := l.times[level]; ok {
+		return duration
+	}
+	return curveAmount(l.buildingType.BaseBuildTime, l.buildingType.BuildTimeMultiplier, l.buildingType.BuildTimeExponent, level)
+}
+
+// loadLevelCosts reads the cost tables for one building type.
+func loadLevelCosts(ctx context.Context, q *db.Queries, buildingType db.BuildingType) (levelCosts, error) {
+	curves, err := q.GetBuildingCostCurves(ctx, buildingType.TypeName)
+	if err != nil {
+		return levelCosts{}, fmt.Errorf("failed to get cost curves: %w", err)
+	}
+
+	costs, err := q.GetBuildingLevelCosts(ctx, buildingType.TypeName)
+	if err != nil {
+		return levelCosts{}, fmt.Errorf("failed to get level costs: %w", err)
+	}
+
+	times, err := q.GetBuildingLevelTimes(ctx, buildingType.TypeName)
+	if err != nil {
+		return levelCosts{}, fmt.Errorf("failed to get level times: %w", err)
+	}
+
+	return newLevelCosts(buildingType, curves, costs, times), nil
+}
+
+// GetBuildingLevels returns the cost, build time, production and effects of
+// every level of a building type.
+func (s *Service) GetBuildingLevels(ctx context.Context, typeName string) ([]BuildingLevel, error) {
+	buildingType, err := s.queries.GetBuildingTypeByName(ctx, typeName)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrBuildingTypeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get building type: %w", err)
+	}
+
+	costs, err := loadLevelCosts(ctx, s.queries, buildingType)
+	if err != nil {
+		return nil, err
+	}
+
+	production, err := s.queries.GetAllProductionForBuildingType(ctx, typeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get production: %w", err)
+	}
+
+	effects, err := s.queries.GetBuildingEffects(ctx, typeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get effects: %w", err)
+	}
+
+	levels := make([]BuildingLevel, 0, buildingType.MaxLevel)
+	for level := int32(1); level <= buildingType.MaxLevel; level++ {
+		flows := []ProductionFlow{}
+		for _, p := range production {
+			if p.Level == level {
+				flows = append(flows, ProductionFlow{
+					ResourceType: p.ResourceType,
+					Flow:         p.Flow,
+					PerHour:      p.ProductionPerHour,
+				})
+			}
+		}
+
+		levelEffects := map[string]float64{}
+		for _, effect := range effects {
+			if effect.Level == level {
+				levelEffects[effect.Effect] = effect.Value
+			}
+		}
+
+		levels = append(levels, BuildingLevel{
+			Level:           level,
+			Cost:            costs.cost(level),
+			DurationSeconds: costs.duration(level),
+			Production:      flows,
+			Effects:         levelEffects,
+		})
+	}
+
+	return levels, nil
+}
@@ -0,0 +1,140 @@
+package island
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bradcypert/stserver/internal/db"
+)
+
+var ErrPrerequisitesNotMet = errors.New("prerequisites not met")
+
+// Prerequisite is one requirement for building a type at Level or above.
+// Met is only set when the requirement was checked against a port.
+type Prerequisite struct {
+	Level            int32  `json:"level"`
+	RequiredBuilding string `json:"required_building,omitempty"`
+	RequiredLevel    int32  `json:"required_level,omitempty"`
+	RequiredResearch string `json:"required_research,omitempty"`
+	Met              *bool  `json:"met,omitempty"`
+}
+
+func (p Prerequisite) String() string {
+	var parts []string
+	if p.RequiredBuilding != "" {
+		parts = append(parts, fmt.Sprintf("%s level %d", p.RequiredBuilding, p.RequiredLevel))
+	}
+	if p.RequiredResearch != "" {
+		parts = append(parts, fmt.Sprintf("%s research", p.RequiredResearch))
+	}
+	return strings.Join(parts, " and ")
+}
+
+// BuildingTypeNode is a building type with what it costs to build and the
+// prerequisites that unlock each of its levels. Unlocked is only set when
+// checked against a port and says whether level 1 can be built there.
+type BuildingTypeNode struct {
+	db.BuildingType
+	Cost          Resources      `json:"cost"`
+	Prerequisites []Prerequisite `json:"prerequisites"`
+	Unlocked      *bool          `json:"unlocked,omitempty"`
+}
+
+func newPrerequisite(level int32, building, research string, requiredLevel int32) Prerequisite {
+	return Prerequisite{
+		Level:            level,
+		RequiredBuilding: building,
+		RequiredLevel:    requiredLevel,
+		RequiredResearch: research,
+	}
+}
+
+// GetBuildingTree returns every building type with its prerequisites. When
+// portID is given, each prerequisite also says whether that port meets it.
+func (s *Service) GetBuildingTree(ctx context.Context, portID *int32) ([]BuildingTypeNode, error) {
+	buildingTypes, err := s.queries.GetAllBuildingTypes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get building types: %w", err)
+	}
+
+	curves, err := s.queries.GetAllBuildingCostCurves(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cost curves: %w", err)
+	}
+
+	levelCosts, err := s.queries.GetAllBuildingLevelCosts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get level costs: %w", err)
+	}
+
+	byType := make(map[string][]Prerequisite)
+	if portID == nil {
+		prerequisites, err := s.queries.GetAllBuildingPrerequisites(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get prerequisites: %w", err)
+		}
+		for _, p := range prerequisites {
+			byType[p.BuildingType] = append(byType[p.BuildingType], newPrerequisite(p.Level, p.RequiredBuilding.String, p.RequiredResearch.String, p.RequiredLevel.Int32))
+		}
+	} else {
+		statuses, err := s.queries.GetPortPrerequisiteStatus(ctx, *portID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get prerequisites: %w", err)
+		}
+		for _, p := range statuses {
+			prerequisite := newPrerequisite(p.Level, p.RequiredBuilding.String, p.RequiredResearch.String, p.RequiredLevel.Int32)
+			prerequisite.Met = &p.Met
+			byType[p.BuildingType] = append(byType[p.BuildingType], prerequisite)
+		}
+	}
+
+	nodes := make([]BuildingTypeNode, 0, len(buildingTypes))
+	for _, buildingType := range buildingTypes {
+		node := BuildingTypeNode{
+			BuildingType:  buildingType,
+			Cost:          newLevelCosts(buildingType, curves, levelCosts, nil).cost(1),
+			Prerequisites: byType[buildingType.TypeName],
+		}
+		if node.Prerequisites == nil {
+			node.Prerequisites = []Prerequisite{}
+		}
+
+		if portID != nil {
+			unlocked := true
+			for _, p := range node.Prerequisites {
+				if p.Level <= 1 && !*p.Met {
+					unlocked = false
+				}
+			}
+			node.Unlocked = &unlocked
+		}
+
+		nodes = append(nodes, node)
+	}
+
+	return nodes, nil
+}
+
+// checkPrerequisites returns ErrPrerequisitesNotMet, listing what is missing,
+// unless the port may build buildingType up to level.
+func (s *Service) checkPrerequisites(ctx context.Context, q *db.Queries, portID int32, buildingType string, level int32) error {
+	statuses, err := q.GetPortPrerequisiteStatus(ctx, portID)
+	if err != nil {
+		return fmt.Errorf("failed to check prerequisites: %w", err)
+	}
+
+	var missing []string
+	for _, p := range statuses {
+		if p.BuildingType != buildingType || p.Level > level || p.Met {
+			continue
+		}
+		missing = append(missing, newPrerequisite(p.Level, p.RequiredBuilding.String, p.RequiredResearch.String, p.RequiredLevel.Int32).String())
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: requires %s", ErrPrerequisitesNotMet, strings.Join(missing, ", "))
+	}
+	return nil
+}
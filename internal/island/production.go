@@ -0,0 +1,392 @@
+package island
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/bradcypert/stserver/internal/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Resources holds an amount per resource type, keyed by the names in the
+// resource_types catalog.
+type Resources map[string]int32
+
+// portResources returns the amount of each resource the port has stored.
+// Resources it has none of are left out.
+func portResources(ctx context.Context, q *db.Queries, portID int32) (Resources, error) {
+	rows, err := q.GetPortResources(ctx, portID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resources: %w", err)
+	}
+
+	amounts := make(Resources, len(rows))
+	for _, row := range rows {
+		amounts[row.ResourceType] = row.Amount
+	}
+	return amounts, nil
+}
+
+// store adds produced to amount without exceeding capacity. It returns the
+// amount actually stored and the overflow that did not fit. Amounts already
+// over capacity are left alone, they just stop growing.
+func store(amount, produced, capacity int32) (int32, int32) {
+	room := max(capacity-amount, 0)
+	stored := min(produced, room)
+	return stored, produced - stored
+}
+
+func capacityByResource(capacities []db.GetPortStorageCapacityRow) map[string]int32 {
+	byResource := make(map[string]int32, len(capacities))
+	for _, capacity := range capacities {
+		byResource[capacity.ResourceType] = capacity.Capacity
+	}
+	return byResource
+}
+
+func carryByResource(rates []db.PortProduction) map[string]float64 {
+	byResource := make(map[string]float64, len(rates))
+	for _, rate := range rates {
+		byResource[rate.ResourceType] = rate.Carry
+	}
+	return byResource
+}
+
+// flowRate is one resource a building produces or consumes, per hour.
+type flowRate struct {
+	ResourceType string
+	PerHour      float64
+}
+
+// buildingRun is an operating building with what it produces and what it
+// consumes as inputs or upkeep at its current level.
+type buildingRun struct {
+	ID       int32
+	Stalled  string
+	Outputs  []flowRate
+	Consumes []flowRate
+}
+
+// buildingRuns groups a port's building flows by building, with outputs
+// scaled by the island's production multiplier. Buildings that consume
+// nothing come first so converters can use what they produce.
+func buildingRuns(flows []db.GetPortBuildingFlowsRow, multiplier float64) []buildingRun {
+	var runs []buildingRun
+	for _, flow := range flows {
+		if len(runs) == 0 || runs[len(runs)-1].ID != flow.BuildingID {
+			runs = append(runs, buildingRun{ID: flow.BuildingID, Stalled: flow.StalledResource.String})
+		}
+		run := &runs[len(runs)-1]
+
+		rate := flowRate{ResourceType: flow.ResourceType, PerHour: float64(flow.ProductionPerHour)}
+		if flow.Flow == "output" {
+			rate.PerHour *= multiplier
+			run.Outputs = append(run.Outputs, rate)
+		} else {
+			run.Consumes = append(run.Consumes, rate)
+		}
+	}
+
+	ordered := make([]buildingRun, 0, len(runs))
+	for _, run := range runs {
+		if len(run.Consumes) == 0 {
+			ordered = append(ordered, run)
+		}
+	}
+	for _, run := range runs {
+		if len(run.Consumes) > 0 {
+			ordered = append(ordered, run)
+		}
+	}
+	return ordered
+}
+
+// production is the outcome of running a port's buildings for a while.
+// Delta is negative for resources that were consumed on balance.
+type production struct {
+	Delta    Resources
+	Overflow Resources
+	Carry    map[string]float64
+	Stalled  map[int32]string
+}
+
+// runProduction runs a port's buildings for elapsed, starting from amounts.
+// A building short of an input or upkeep resource only runs for the share of
+// the time its stock covers, and is reported as stalled on that resource.
+// When no time has passed, the stalls from the last settlement stand.
+func runProduction(amounts Resources, runs []buildingRun, carries map[string]float64, capacities map[string]int32, elapsed time.Duration) production {
+	result := production{
+		Delta:    Resources{},
+		Overflow: Resources{},
+		Carry:    map[string]float64{},
+		Stalled:  map[int32]string{},
+	}
+
+	if elapsed <= 0 {
+		for _, run := range runs {
+			if run.Stalled != "" {
+				result.Stalled[run.ID] = run.Stalled
+			}
+		}
+		return result
+	}
+
+	hours := elapsed.Hours()
+	available := make(map[string]float64)
+	for _, run := range runs {
+		share := 1.0
+		for _, input := range run.Consumes {
+			need := input.PerHour * hours
+			if need <= 0 {
+				continue
+			}
+
+			stock := available[input.ResourceType] + float64(amounts[input.ResourceType])
+			if covered := max(stock, 0) / need; covered < share {
+				share = covered
+				result.Stalled[run.ID] = input.ResourceType
+			}
+		}
+
+		for _, input := range run.Consumes {
+			available[input.ResourceType] -= input.PerHour * hours * share
+		}
+		for _, output := range run.Outputs {
+			available[output.ResourceType] += output.PerHour * hours * share
+		}
+	}
+
+	for resourceType, change := range available {
+		amount := amounts[resourceType]
+		exact := change + carries[resourceType]
+		whole := math.Floor(exact)
+		delta, carry := int32(whole), exact-whole
+
+		if delta < -amount {
+			// Rounding can't take more than the port has
+			delta, carry = -amount, 0
+		}
+
+		if delta > 0 {
+			stored, overflow := store(amount, delta, capacities[resourceType])
+			if overflow > 0 {
+				// Nothing fractional is owed once storage is full
+				carry = 0
+				result.Overflow[resourceType] = overflow
+			}
+			delta = stored
+		}
+
+		result.Delta[resourceType] = delta
+		result.Carry[resourceType] = carry
+	}
+
+	return result
+}
+
+// settlePort writes the production accrued since the last settlement into
+// the port's stored resources and moves settled_at up to at. It locks the
+// resources row, so q should be bound to a transaction.
+func (s *Service) settlePort(ctx context.Context, q *db.Queries, portID int32, at time.Time) error {
+	resources, err := q.LockPortResources(ctx, portID)
+	if err != nil {
+		return fmt.Errorf("failed to lock resources: %w", err)
+	}
+
+	if !at.After(resources.SettledAt.Time) {
+		return nil
+	}
+
+	flows, err := q.GetPortBuildingFlows(ctx, portID)
+	if err != nil {
+		return fmt.Errorf("failed to get building production: %w", err)
+	}
+
+	rates, err := q.GetPortProduction(ctx, portID)
+	if err != nil {
+		return fmt.Errorf("failed to get production rates: %w", err)
+	}
+
+	capacities, err := q.GetPortStorageCapacity(ctx, portID)
+	if err != nil {
+		return fmt.Errorf("failed to get storage capacity: %w", err)
+	}
+
+	amounts, err := portResources(ctx, q, portID)
+	if err != nil {
+		return err
+	}
+
+	stats, err := s.islandStats(ctx, q, portID)
+	if err != nil {
+		return err
+	}
+
+	runs := buildingRuns(flows, stats.ProductionMultiplier)
+	result := runProduction(amounts, runs, carryByResource(rates), capacityByResource(capacities), at.Sub(resources.SettledAt.Time))
+
+	for resourceType, carry := range result.Carry {
+		err = q.UpdatePortProductionCarry(ctx, db.UpdatePortProductionCarryParams{
+			PortID:       portID,
+			ResourceType: resourceType,
+			Carry:        carry,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to store production carry: %w", err)
+		}
+	}
+
+	produced, consumed := Resources{}, Resources{}
+	for resourceType, delta := range result.Delta {
+		if delta > 0 {
+			produced[resourceType] = delta
+		} else if delta < 0 {
+			consumed[resourceType] = delta
+		}
+	}
+
+	err = credit(ctx, q, portID, produced, LedgerProduction, "")
+	if err != nil {
+		return err
+	}
+
+	for resourceType, delta := range consumed {
+		rows, err := q.ConsumePortResource(ctx, db.ConsumePortResourceParams{
+			PortID:       portID,
+			ResourceType: resourceType,
+			Amount:       -delta,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to consume %s: %w", resourceType, err)
+		}
+		if rows == 0 {
+			return fmt.Errorf("failed to consume %s: %w", resourceType, ErrInsufficientResources)
+		}
+	}
+
+	err = recordLedger(ctx, q, portID, consumed, LedgerProduction, "")
+	if err != nil {
+		return err
+	}
+
+	for _, run := range runs {
+		if result.Stalled[run.ID] == run.Stalled {
+			continue
+		}
+
+		stalled := result.Stalled[run.ID]
+		err = q.SetBuildingStalled(ctx, db.SetBuildingStalledParams{
+			ID:              run.ID,
+			StalledResource: pgtype.Text{String: stalled, Valid: stalled != ""},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update building status: %w", err)
+		}
+	}
+
+	err = q.MarkResourcesSettled(ctx, db.MarkResourcesSettledParams{
+		PortID:    portID,
+		SettledAt: pgtype.Timestamptz{Time: at, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark resources settled: %w", err)
+	}
+
+	return nil
+}
+
+// refreshProductionRates settles the port up to at and then recomputes its
+// production rates from the buildings that are currently operating. Call it
+// whenever a building starts or stops producing.
+func (s *Service) refreshProductionRates(ctx context.Context, q *db.Queries, portID int32, at time.Time) error {
+	err := s.settlePort(ctx, q, portID, at)
+	if err != nil {
+		return err
+	}
+
+	rates, err := q.CalculatePortProductionRates(ctx, portID)
+	if err != nil {
+		return fmt.Errorf("failed to calculate production rates: %w", err)
+	}
+
+	err = q.ClearPortProductionRates(ctx, portID)
+	if err != nil {
+		return fmt.Errorf("failed to clear production rates: %w", err)
+	}
+
+	for _, rate := range rates {
+		err = q.UpsertPortProductionRate(ctx, db.UpsertPortProductionRateParams{
+			PortID:       portID,
+			ResourceType: rate.ResourceType,
+			RatePerHour:  rate.RatePerHour,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to store production rate: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SettlePort materializes a port's accrued production into its stored
+// resources.
+func (s *Service) SettlePort(ctx context.Context, portID int32) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = s.settlePort(ctx, s.queries.WithTx(tx), portID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ResourceStorage describes how full a port's storage is for one resource.
+// Overflow is production lost to full storage since the last settlement.
+type ResourceStorage struct {
+	ResourceType string  `json:"resource_type"`
+	Amount       int32   `json:"amount"`
+	Capacity     int32   `json:"capacity"`
+	FillPercent  float64 `json:"fill_percent"`
+	Overflow     int32   `json:"overflow"`
+}
+
+// withPendingProduction returns the port's resource amounts with production
+// accrued since the last settlement applied, the storage report for each
+// cataloged resource, and the resource each stalled building is missing.
+func withPendingProduction(amounts Resources, settledAt pgtype.Timestamptz, flows []db.GetPortBuildingFlowsRow, stats IslandStats, rates []db.PortProduction, capacities []db.GetPortStorageCapacityRow, now time.Time) (Resources, []ResourceStorage, map[int32]string) {
+	var elapsed time.Duration
+	if settledAt.Valid {
+		elapsed = now.Sub(settledAt.Time)
+	}
+	runs := buildingRuns(flows, stats.ProductionMultiplier)
+	result := runProduction(amounts, runs, carryByResource(rates), capacityByResource(capacities), elapsed)
+
+	current := make(Resources, len(capacities))
+	storage := make([]ResourceStorage, 0, len(capacities))
+	for _, capacity := range capacities {
+		amount := amounts[capacity.ResourceType] + result.Delta[capacity.ResourceType]
+		current[capacity.ResourceType] = amount
+
+		fillPercent := 0.0
+		if capacity.Capacity > 0 {
+			fillPercent = math.Round(float64(amount)/float64(capacity.Capacity)*10000) / 100
+		}
+
+		storage = append(storage, ResourceStorage{
+			ResourceType: capacity.ResourceType,
+			Amount:       amount,
+			Capacity:     capacity.Capacity,
+			FillPercent:  fillPercent,
+			Overflow:     result.Overflow[capacity.ResourceType],
+		})
+	}
+
+	return current, storage, result.Stalled
+}
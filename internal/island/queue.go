@@ -0,0 +1,562 @@
+package island
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/bradcypert/stserver/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// baseBuildSlots is how many constructions an island can run at once
+	// before any build_slots effects are added.
+	baseBuildSlots = 1
+
+	queueStatusQueued = "queued"
+	queueStatusActive = "active"
+)
+
+var (
+	ErrQueueItemNotFound = errors.New("construction queue item not found")
+	ErrQueueItemStarted  = errors.New("construction has already started")
+	ErrNoConstruction    = errors.New("building has no construction in progress")
+)
+
+// ConstructionQueueItem is a queue entry along with when it is expected to
+// start and finish. Active items report their actual times; queued items
+// are estimated from the slots that free up ahead of them.
+type ConstructionQueueItem struct {
+	db.ConstructionQueue
+	Cost              Resources `json:"cost"`
+	EstimatedStartAt  time.Time `json:"estimated_start_at"`
+	EstimatedFinishAt time.Time `json:"estimated_finish_at"`
+}
+
+// ConstructionQueue is a port's build queue and how many items it can run
+// at once.
+type ConstructionQueue struct {
+	Slots int32                   `json:"slots"`
+	Items []ConstructionQueueItem `json:"items"`
+}
+
+func (s *Service) buildSlots(ctx context.Context, q *db.Queries, portID int32) (int32, error) {
+	stats, err := s.islandStats(ctx, q, portID)
+	if err != nil {
+		return 0, err
+	}
+	return stats.BuildSlots, nil
+}
+
+// queueItemCost returns what was paid for a queue item.
+func queueItemCost(ctx context.Context, q *db.Queries, itemID int32) (Resources, error) {
+	rows, err := q.GetConstructionQueueCosts(ctx, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get construction cost: %w", err)
+	}
+
+	cost := make(Resources, len(rows))
+	for _, row := range rows {
+		cost[row.ResourceType] = row.Amount
+	}
+	return cost, nil
+}
+
+// estimateQueue works out start and finish times for every item, starting
+// queued items in position order as active ones finish.
+func estimateQueue(items []db.ConstructionQueue, costs map[int32]Resources, slots int32, now time.Time) []ConstructionQueueItem {
+	var running []time.Time
+	for _, item := range items {
+		if item.Status == queueStatusActive {
+			running = append(running, item.FinishesAt.Time)
+		}
+	}
+
+	estimated := make([]ConstructionQueueItem, 0, len(items))
+	cursor := now
+	for _, item := range items {
+		if costs[item.ID] == nil {
+			costs[item.ID] = Resources{}
+		}
+
+		if item.Status == queueStatusActive {
+			estimated = append(estimated, ConstructionQueueItem{
+				ConstructionQueue: item,
+				Cost:              costs[item.ID],
+				EstimatedStartAt:  item.StartedAt.Time,
+				EstimatedFinishAt: item.FinishesAt.Time,
+			})
+			continue
+		}
+
+		slices.SortFunc(running, func(a, b time.Time) int { return a.Compare(b) })
+		for int32(len(running)) >= max(slots, 1) {
+			if running[0].After(cursor) {
+				cursor = running[0]
+			}
+			running = running[1:]
+		}
+
+		finish := cursor.Add(time.Duration(item.DurationSeconds) * time.Second)
+		running = append(running, finish)
+		estimated = append(estimated, ConstructionQueueItem{
+			ConstructionQueue: item,
+			Cost:              costs[item.ID],
+			EstimatedStartAt:  cursor,
+			EstimatedFinishAt: finish,
+		})
+	}
+
+	return estimated
+}
+
+// GetConstructionQueue returns the port's build queue with estimated times.
+func (s *Service) GetConstructionQueue(ctx context.Context, portID int32) (*ConstructionQueue, error) {
+	items, err := s.queries.GetPortConstructionQueue(ctx, portID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get construction queue: %w", err)
+	}
+
+	costRows, err := s.queries.GetPortConstructionQueueCosts(ctx, portID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get construction costs: %w", err)
+	}
+
+	costs := make(map[int32]Resources)
+	for _, row := range costRows {
+		if costs[row.QueueItemID] == nil {
+			costs[row.QueueItemID] = Resources{}
+		}
+		costs[row.QueueItemID][row.ResourceType] = row.Amount
+	}
+
+	slots, err := s.buildSlots(ctx, s.queries, portID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConstructionQueue{
+		Slots: slots,
+		Items: estimateQueue(items, costs, slots, time.Now()),
+	}, nil
+}
+
+// startQueuedConstructions starts queued items in position order while the
+// port has free build slots. The caller must hold the port's resources lock
+// so two starts can't race for the same slot.
+func (s *Service) startQueuedConstructions(ctx context.Context, q *db.Queries, portID int32, at time.Time) error {
+	slots, err := s.buildSlots(ctx, q, portID)
+	if err != nil {
+		return err
+	}
+
+	for {
+		active, err := q.CountActiveConstructions(ctx, portID)
+		if err != nil {
+			return fmt.Errorf("failed to count active constructions: %w", err)
+		}
+		if active >= int64(slots) {
+			return nil
+		}
+
+		item, err := q.GetNextQueuedConstruction(ctx, portID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get next queued construction: %w", err)
+		}
+
+		err = s.startConstruction(ctx, q, item, at)
+		if err != nil {
+			return err
+		}
+
+		// A carpenter that just went under construction gives up its slot
+		slots, err = s.buildSlots(ctx, q, portID)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Service) startConstruction(ctx context.Context, q *db.Queries, item db.ConstructionQueue, at time.Time) error {
+	finishesAt := pgtype.Timestamptz{Time: at.Add(time.Duration(item.DurationSeconds) * time.Second), Valid: true}
+
+	buildingID := item.BuildingID
+	if buildingID.Valid {
+		err := q.UpgradeBuilding(ctx, db.UpgradeBuildingParams{
+			ID:                     buildingID.Int32,
+			ConstructionCompleteAt: finishesAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to start upgrade: %w", err)
+		}
+
+		// The building stops producing while it is upgraded
+		err = s.refreshProductionRates(ctx, q, item.PortID, at)
+		if err != nil {
+			return fmt.Errorf("failed to update production rates: %w", err)
+		}
+	} else {
+		building, err := q.CreateBuildingConstruction(ctx, db.CreateBuildingConstructionParams{
+			PortID:                 item.PortID,
+			Type:                   item.BuildingType,
+			ConstructionCompleteAt: finishesAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create building: %w", err)
+		}
+		buildingID = pgtype.Int4{Int32: building.ID, Valid: true}
+	}
+
+	err := q.StartConstructionQueueItem(ctx, db.StartConstructionQueueItemParams{
+		ID:         item.ID,
+		BuildingID: buildingID,
+		StartedAt:  pgtype.Timestamptz{Time: at, Valid: true},
+		FinishesAt: finishesAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start queue item: %w", err)
+	}
+
+	return nil
+}
+
+// enqueueConstruction pays the item's cost, adds it to the end of the port's
+// queue and starts it straight away if a slot is free. Nothing is queued if
+// the port can't afford it.
+func (s *Service) enqueueConstruction(ctx context.Context, params db.CreateConstructionQueueItemParams, cost Resources) (*ConstructionQueueItem, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.queries.WithTx(tx)
+	now := time.Now()
+
+	// Settling takes the port lock for the rest of the transaction
+	err = s.settlePort(ctx, q, params.PortID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only one upgrade per building can be queued at a time. Checked under
+	// the port lock so two requests can't both queue one.
+	if params.BuildingID.Valid {
+		building, err := q.GetBuilding(ctx, params.BuildingID.Int32)
+		if err != nil {
+			return nil, fmt.Errorf("building not found: %w", err)
+		}
+		if building.UnderConstruction || building.DemolishAt.Valid {
+			return nil, fmt.Errorf("building can't be upgraded right now")
+		}
+
+		_, err = q.GetConstructionQueueItemForBuilding(ctx, params.BuildingID)
+		if err == nil {
+			return nil, fmt.Errorf("building already has an upgrade queued")
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("failed to check construction queue: %w", err)
+		}
+	}
+
+	item, err := q.CreateConstructionQueueItem(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue construction: %w", err)
+	}
+
+	for resourceType, amount := range cost {
+		if amount == 0 {
+			continue
+		}
+
+		err = q.AddConstructionQueueCost(ctx, db.AddConstructionQueueCostParams{
+			QueueItemID:  item.ID,
+			ResourceType: resourceType,
+			Amount:       amount,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to record construction cost: %w", err)
+		}
+	}
+
+	reason := LedgerConstruction
+	if params.BuildingID.Valid {
+		reason = LedgerUpgrade
+	}
+
+	err = s.spend(ctx, q, params.PortID, cost, reason, LedgerReference(item.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.startQueuedConstructions(ctx, q, params.PortID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Return the item as started, if it was, with its estimated times
+	queue, err := s.GetConstructionQueue(ctx, params.PortID)
+	if err != nil {
+		return nil, err
+	}
+	for _, queued := range queue.Items {
+		if queued.ID == item.ID {
+			return &queued, nil
+		}
+	}
+
+	// Already finished, as can happen with an instant build
+	return &ConstructionQueueItem{ConstructionQueue: item, Cost: cost}, nil
+}
+
+// queuedItem loads a queue item that belongs to portID and has not started.
+func queuedItem(ctx context.Context, q *db.Queries, portID, itemID int32) (db.ConstructionQueue, error) {
+	item, err := q.GetConstructionQueueItem(ctx, itemID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && item.PortID != portID) {
+		return item, ErrQueueItemNotFound
+	}
+	if err != nil {
+		return item, fmt.Errorf("failed to get queue item: %w", err)
+	}
+
+	if item.Status != queueStatusQueued {
+		return item, ErrQueueItemStarted
+	}
+
+	return item, nil
+}
+
+// renumberQueue rewrites positions so queued items follow the given order,
+// after any active items.
+func renumberQueue(ctx context.Context, q *db.Queries, items []db.ConstructionQueue) error {
+	for i, item := range items {
+		position := int32(i + 1)
+		if item.Position == position {
+			continue
+		}
+
+		err := q.UpdateConstructionQueuePosition(ctx, db.UpdateConstructionQueuePositionParams{
+			ID:       item.ID,
+			Position: position,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update queue position: %w", err)
+		}
+	}
+	return nil
+}
+
+// MoveQueuedConstruction moves a queued item to position among the items
+// still waiting for a slot, where 1 is next to start.
+func (s *Service) MoveQueuedConstruction(ctx context.Context, portID, itemID, position int32) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.queries.WithTx(tx)
+
+	// Serializes queue changes for the port
+	_, err = q.LockPortResources(ctx, portID)
+	if err != nil {
+		return fmt.Errorf("failed to lock resources: %w", err)
+	}
+
+	_, err = queuedItem(ctx, q, portID, itemID)
+	if err != nil {
+		return err
+	}
+
+	items, err := q.GetPortConstructionQueue(ctx, portID)
+	if err != nil {
+		return fmt.Errorf("failed to get construction queue: %w", err)
+	}
+
+	var active, queued []db.ConstructionQueue
+	var moved db.ConstructionQueue
+	for _, item := range items {
+		switch {
+		case item.ID == itemID:
+			moved = item
+		case item.Status == queueStatusActive:
+			active = append(active, item)
+		default:
+			queued = append(queued, item)
+		}
+	}
+
+	index := int(min(max(position, 1), int32(len(queued)+1))) - 1
+	queued = slices.Insert(queued, index, moved)
+
+	err = renumberQueue(ctx, q, append(active, queued...))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RemoveQueuedConstruction takes an item that hasn't started off the queue
+// and refunds what was paid for it.
+func (s *Service) RemoveQueuedConstruction(ctx context.Context, portID, itemID int32) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.queries.WithTx(tx)
+
+	err = s.settlePort(ctx, q, portID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	item, err := queuedItem(ctx, q, portID, itemID)
+	if err != nil {
+		return err
+	}
+
+	cost, err := queueItemCost(ctx, q, item.ID)
+	if err != nil {
+		return err
+	}
+
+	err = q.DeleteConstructionQueueItem(ctx, item.ID)
+	if err != nil {
+		return fmt.Errorf("failed to remove queue item: %w", err)
+	}
+
+	err = credit(ctx, q, portID, cost, LedgerRefund, LedgerReference(item.ID))
+	if err != nil {
+		return fmt.Errorf("failed to refund resources: %w", err)
+	}
+
+	items, err := q.GetPortConstructionQueue(ctx, portID)
+	if err != nil {
+		return fmt.Errorf("failed to get construction queue: %w", err)
+	}
+
+	err = renumberQueue(ctx, q, items)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// CancelConstruction stops the construction or upgrade queued or running on
+// a building. A new building is removed and an upgrade goes back to the
+// previous level. Items that never started are refunded in full, running
+// ones by the configured share. It returns what was refunded.
+func (s *Service) CancelConstruction(ctx context.Context, buildingID int32) (Resources, error) {
+	building, err := s.queries.GetBuilding(ctx, buildingID)
+	if err != nil {
+		return nil, fmt.Errorf("building not found: %w", err)
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.queries.WithTx(tx)
+	now := time.Now()
+
+	err = s.settlePort(ctx, q, building.PortID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Look the item up under the port lock so it can't complete meanwhile
+	item, err := q.GetConstructionQueueItemForBuilding(ctx, pgtype.Int4{Int32: buildingID, Valid: true})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNoConstruction
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get construction: %w", err)
+	}
+
+	share := 1.0
+	if item.Status == queueStatusActive {
+		share = s.config.CancelRefundShare
+	}
+
+	cost, err := queueItemCost(ctx, q, item.ID)
+	if err != nil {
+		return nil, err
+	}
+	refund := make(Resources, len(cost))
+	for resourceType, amount := range cost {
+		refund[resourceType] = int32(float64(amount) * share)
+	}
+
+	err = q.DeleteConstructionQueueItem(ctx, item.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove queue item: %w", err)
+	}
+
+	if item.Status == queueStatusActive {
+		if item.TargetLevel <= 1 {
+			err = q.DeleteBuilding(ctx, buildingID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to remove building: %w", err)
+			}
+		} else {
+			err = q.RevertBuildingUpgrade(ctx, db.RevertBuildingUpgradeParams{
+				ID:    buildingID,
+				Level: item.TargetLevel - 1,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to revert upgrade: %w", err)
+			}
+		}
+
+		// The building is back in operation, or gone
+		err = s.refreshProductionRates(ctx, q, building.PortID, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update production rates: %w", err)
+		}
+	}
+
+	err = credit(ctx, q, building.PortID, refund, LedgerRefund, LedgerReference(item.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to refund resources: %w", err)
+	}
+
+	err = s.startQueuedConstructions(ctx, q, building.PortID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := q.GetPortConstructionQueue(ctx, building.PortID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get construction queue: %w", err)
+	}
+
+	err = renumberQueue(ctx, q, items)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
@@ -2,23 +2,49 @@ package island
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/bradcypert/stserver/internal/db"
+	"github.com/bradcypert/stserver/internal/events"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// Config holds the tunable rules for island management.
+type Config struct {
+	// CancelRefundShare is the fraction of a construction's cost returned
+	// when it is cancelled after it has started.
+	CancelRefundShare float64
+	// DemolishSalvageShare is the fraction of a building's invested cost
+	// returned when it is demolished.
+	DemolishSalvageShare float64
+	// DemolishTimeShare is how long demolition takes as a fraction of the
+	// building type's base build time.
+	DemolishTimeShare float64
+}
+
+var DefaultConfig = Config{
+	CancelRefundShare:    0.5,
+	DemolishSalvageShare: 0.25,
+	DemolishTimeShare:    0.5,
+}
+
 type Service struct {
 	queries *db.Queries
 	pool    *pgxpool.Pool
+	events  *events.Queue
+	config  Config
 }
 
-func NewService(pool *pgxpool.Pool) *Service {
+func NewService(pool *pgxpool.Pool, queue *events.Queue, config Config) *Service {
 	return &Service{
 		queries: db.New(pool),
 		pool:    pool,
+		events:  queue,
+		config:  config,
 	}
 }
 
@@ -28,8 +54,33 @@ type BuildingConstructionRequest struct {
 }
 
 type IslandOverview struct {
-	Port      db.GetPortWithResourcesRow `json:"port"`
-	Buildings []db.GetPortBuildingsRow   `json:"buildings"`
+	Port       db.GetPortWithResourcesRow `json:"port"`
+	Resources  Resources                  `json:"resources"`
+	Production []db.PortProduction        `json:"production"`
+	Storage    []ResourceStorage          `json:"storage"`
+	Buildings  []PortBuilding             `json:"buildings"`
+	Stats      IslandStats                `json:"stats"`
+	Queue      *ConstructionQueue         `json:"construction_queue"`
+}
+
+// PortBuilding is a building with whether it is operating. A building that
+// ran out of an input or upkeep resource reports "stalled: missing <resource>".
+type PortBuilding struct {
+	db.GetPortBuildingsRow
+	Status string `json:"status"`
+}
+
+func buildingStatus(building db.GetPortBuildingsRow, stalled map[int32]string) string {
+	switch {
+	case building.UnderConstruction:
+		return "under_construction"
+	case building.DemolishAt.Valid:
+		return "demolishing"
+	case stalled[building.ID] != "":
+		return "stalled: missing " + stalled[building.ID]
+	default:
+		return "operating"
+	}
 }
 
 func (s *Service) GetIslandOverview(ctx context.Context, portID int32) (*IslandOverview, error) {
@@ -39,239 +90,246 @@ func (s *Service) GetIslandOverview(ctx context.Context, portID int32) (*IslandO
 		return nil, fmt.Errorf("failed to get port: %w", err)
 	}
 
+	// Stored amounts are only current as of the last settlement
+	production, err := s.queries.GetPortProduction(ctx, portID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get production: %w", err)
+	}
+
+	capacities, err := s.queries.GetPortStorageCapacity(ctx, portID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage capacity: %w", err)
+	}
+
+	amounts, err := portResources(ctx, s.queries, portID)
+	if err != nil {
+		return nil, err
+	}
+
+	flows, err := s.queries.GetPortBuildingFlows(ctx, portID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get building production: %w", err)
+	}
+
+	stats, err := s.islandStats(ctx, s.queries, portID)
+	if err != nil {
+		return nil, err
+	}
+
+	amounts, storage, stalled := withPendingProduction(amounts, port.ResourcesSettledAt, flows, stats, production, capacities, time.Now())
+
 	// Get buildings
-	buildings, err := s.queries.GetPortBuildings(ctx, portID)
+	portBuildings, err := s.queries.GetPortBuildings(ctx, portID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get buildings: %w", err)
 	}
 
+	buildings := make([]PortBuilding, 0, len(portBuildings))
+	for _, building := range portBuildings {
+		buildings = append(buildings, PortBuilding{
+			GetPortBuildingsRow: building,
+			Status:              buildingStatus(building, stalled),
+		})
+	}
+
+	queue, err := s.GetConstructionQueue(ctx, portID)
+	if err != nil {
+		return nil, err
+	}
+
 	return &IslandOverview{
-		Port:      port,
-		Buildings: buildings,
+		Port:       port,
+		Resources:  amounts,
+		Production: production,
+		Storage:    storage,
+		Buildings:  buildings,
+		Stats:      stats,
+		Queue:      queue,
 	}, nil
 }
 
-func (s *Service) ConstructBuilding(ctx context.Context, req BuildingConstructionRequest) (*db.Building, error) {
+func (s *Service) ConstructBuilding(ctx context.Context, req BuildingConstructionRequest) (*ConstructionQueueItem, error) {
 	// Get building type info
 	buildingType, err := s.queries.GetBuildingTypeByName(ctx, req.BuildingType)
 	if err != nil {
 		return nil, fmt.Errorf("invalid building type: %w", err)
 	}
 
-	// Check if player has enough resources
-	hasResources, err := s.queries.CheckResourceAvailability(ctx, db.CheckResourceAvailabilityParams{
-		PortID:   req.PortID,
-		HasWood:  buildingType.BaseCostWood,
-		HasIron:  buildingType.BaseCostIron,
-		HasGold:  buildingType.BaseCostGold,
-	})
+	err = s.checkPrerequisites(ctx, s.queries, req.PortID, req.BuildingType, 1)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check resources: %w", err)
+		return nil, err
 	}
 
-	if !hasResources.HasWood || !hasResources.HasIron || !hasResources.HasGold {
-		return nil, fmt.Errorf("insufficient resources for construction")
+	costs, err := loadLevelCosts(ctx, s.queries, buildingType)
+	if err != nil {
+		return nil, err
 	}
 
-	// Consume resources
-	err = s.queries.ConsumeResourcesFromPort(ctx, db.ConsumeResourcesFromPortParams{
-		PortID: req.PortID,
-		Wood:   buildingType.BaseCostWood,
-		Iron:   buildingType.BaseCostIron,
-		Gold:   buildingType.BaseCostGold,
-	})
+	stats, err := s.islandStats(ctx, s.queries, req.PortID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to consume resources: %w", err)
+		return nil, err
 	}
 
-	// Calculate completion time
-	completionTime := time.Now().Add(time.Duration(buildingType.BaseBuildTime) * time.Second)
-
-	// Create building under construction
-	building, err := s.queries.CreateBuildingConstruction(ctx, db.CreateBuildingConstructionParams{
-		PortID:                 req.PortID,
-		Type:                   req.BuildingType,
-		ConstructionCompleteAt: pgtype.Timestamptz{Time: completionTime, Valid: true},
-	})
+	// Pay for and queue the construction, it starts as soon as a build
+	// slot is free
+	item, err := s.enqueueConstruction(ctx, db.CreateConstructionQueueItemParams{
+		PortID:          req.PortID,
+		BuildingType:    req.BuildingType,
+		TargetLevel:     1,
+		DurationSeconds: stats.buildTime(costs.duration(1)),
+	}, costs.cost(1))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create building: %w", err)
+		return nil, err
 	}
 
-	return &building, nil
+	return item, nil
 }
 
-func (s *Service) UpgradeBuilding(ctx context.Context, buildingID int32) error {
+func (s *Service) UpgradeBuilding(ctx context.Context, buildingID int32) (*ConstructionQueueItem, error) {
 	// Get building info
 	building, err := s.queries.GetBuilding(ctx, buildingID)
 	if err != nil {
-		return fmt.Errorf("building not found: %w", err)
+		return nil, fmt.Errorf("building not found: %w", err)
 	}
 
 	if building.UnderConstruction {
-		return fmt.Errorf("building is already under construction")
+		return nil, fmt.Errorf("building is already under construction")
+	}
+
+	if building.DemolishAt.Valid {
+		return nil, fmt.Errorf("building is being demolished")
 	}
 
 	// Get building type info
 	buildingType, err := s.queries.GetBuildingTypeByName(ctx, building.Type)
 	if err != nil {
-		return fmt.Errorf("invalid building type: %w", err)
+		return nil, fmt.Errorf("invalid building type: %w", err)
 	}
 
 	if building.Level >= buildingType.MaxLevel {
-		return fmt.Errorf("building is already at maximum level")
-	}
-
-	// Calculate upgrade cost (increases with level)
-	upgradeCostMultiplier := int32(building.Level + 1)
-	upgradeCostWood := buildingType.BaseCostWood * upgradeCostMultiplier
-	upgradeCostIron := buildingType.BaseCostIron * upgradeCostMultiplier
-	upgradeCostGold := buildingType.BaseCostGold * upgradeCostMultiplier
-
-	// Check resources
-	hasResources, err := s.queries.CheckResourceAvailability(ctx, db.CheckResourceAvailabilityParams{
-		PortID:   building.PortID,
-		HasWood:  upgradeCostWood,
-		HasIron:  upgradeCostIron,
-		HasGold:  upgradeCostGold,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to check resources: %w", err)
+		return nil, fmt.Errorf("building is already at maximum level")
 	}
 
-	if !hasResources.HasWood || !hasResources.HasIron || !hasResources.HasGold {
-		return fmt.Errorf("insufficient resources for upgrade")
+	err = s.checkPrerequisites(ctx, s.queries, building.PortID, building.Type, building.Level+1)
+	if err != nil {
+		return nil, err
 	}
 
-	// Consume resources
-	err = s.queries.ConsumeResourcesFromPort(ctx, db.ConsumeResourcesFromPortParams{
-		PortID: building.PortID,
-		Wood:   upgradeCostWood,
-		Iron:   upgradeCostIron,
-		Gold:   upgradeCostGold,
-	})
+	// Cost and time for the next level come from the type's level tables
+	costs, err := loadLevelCosts(ctx, s.queries, buildingType)
 	if err != nil {
-		return fmt.Errorf("failed to consume resources: %w", err)
+		return nil, err
 	}
+	targetLevel := building.Level + 1
 
-	// Calculate upgrade time (longer than base construction)
-	upgradeTime := time.Duration(buildingType.BaseBuildTime) * time.Duration(building.Level+1) * time.Second
-	completionTime := time.Now().Add(upgradeTime)
+	stats, err := s.islandStats(ctx, s.queries, building.PortID)
+	if err != nil {
+		return nil, err
+	}
 
-	// Start upgrade
-	err = s.queries.UpgradeBuilding(ctx, db.UpgradeBuildingParams{
-		ID:                     buildingID,
-		ConstructionCompleteAt: pgtype.Timestamptz{Time: completionTime, Valid: true},
-	})
+	// Pay for and queue the upgrade, the building keeps producing until it
+	// starts
+	item, err := s.enqueueConstruction(ctx, db.CreateConstructionQueueItemParams{
+		PortID:          building.PortID,
+		BuildingID:      pgtype.Int4{Int32: buildingID, Valid: true},
+		BuildingType:    building.Type,
+		TargetLevel:     targetLevel,
+		DurationSeconds: stats.buildTime(costs.duration(targetLevel)),
+	}, costs.cost(targetLevel))
 	if err != nil {
-		return fmt.Errorf("failed to start upgrade: %w", err)
+		return nil, err
 	}
 
-	return nil
+	return item, nil
 }
 
-func (s *Service) ProcessResourceGeneration(ctx context.Context) error {
-	// Get all buildings ready for production
-	cutoffTime := time.Now().Add(-5 * time.Second) // Last tick was 5 seconds ago
-	buildings, err := s.queries.GetBuildingsReadyForProduction(ctx, pgtype.Timestamptz{Time: cutoffTime, Valid: true})
+// CompleteConstructions completes every construction that is due. One
+// failing doesn't hold up the rest; their errors are returned together.
+func (s *Service) CompleteConstructions(ctx context.Context) error {
+	// Get constructions that should be completed
+	dueConstructions, err := s.queries.GetDueConstructions(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get buildings ready for production: %w", err)
+		return fmt.Errorf("failed to get completed constructions: %w", err)
 	}
 
-	for _, building := range buildings {
-		err := s.processProductionForBuilding(ctx, building)
+	var errs []error
+	for _, item := range dueConstructions {
+		err := s.completeConstruction(ctx, item)
 		if err != nil {
-			// Log error but continue processing other buildings
-			fmt.Printf("Error processing production for building %d: %v\n", building.ID, err)
-			continue
-		}
-
-		// Update last production time
-		err = s.queries.UpdateBuildingLastProduction(ctx, building.ID)
-		if err != nil {
-			fmt.Printf("Error updating last production time for building %d: %v\n", building.ID, err)
+			errs = append(errs, fmt.Errorf("failed to complete construction for building %d: %w", item.BuildingID.Int32, err))
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
-func (s *Service) processProductionForBuilding(ctx context.Context, building db.GetBuildingsReadyForProductionRow) error {
-	// Get production rates for this building type and level
-	productions, err := s.queries.GetProductionRatesForBuilding(ctx, db.GetProductionRatesForBuildingParams{
-		TypeName: building.Type,
-		Level:    building.Level,
-	})
+func (s *Service) completeConstruction(ctx context.Context, item db.ConstructionQueue) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.queries.WithTx(tx)
+	completedAt := item.FinishesAt.Time
+
+	// Settle before completing so a finished warehouse only raises storage
+	// capacity from its completion onwards.
+	err = s.settlePort(ctx, q, item.PortID, completedAt)
 	if err != nil {
-		return fmt.Errorf("failed to get production rates: %w", err)
+		return err
 	}
 
-	if len(productions) == 0 {
-		// This building type doesn't produce resources
+	// Another sweep or a cancellation may have got to the item first
+	item, err = q.LockConstructionQueueItem(ctx, item.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get construction: %w", err)
+	}
+	if item.Status != queueStatusActive {
 		return nil
 	}
 
-	// Calculate resource additions
-	var wood, iron, rum, sugar, tobacco, cotton, coffee, grain, gold, silver int32
-
-	for _, prod := range productions {
-		switch prod.ResourceType {
-		case "wood":
-			wood += prod.ProductionRate
-		case "iron":
-			iron += prod.ProductionRate
-		case "rum":
-			rum += prod.ProductionRate
-		case "sugar":
-			sugar += prod.ProductionRate
-		case "tobacco":
-			tobacco += prod.ProductionRate
-		case "cotton":
-			cotton += prod.ProductionRate
-		case "coffee":
-			coffee += prod.ProductionRate
-		case "grain":
-			grain += prod.ProductionRate
-		case "gold":
-			gold += prod.ProductionRate
-		case "silver":
-			silver += prod.ProductionRate
-		}
+	err = q.CompleteBuildingConstruction(ctx, item.BuildingID.Int32)
+	if err != nil {
+		return err
 	}
 
-	// Add resources to port
-	err = s.queries.AddResourcesToPort(ctx, db.AddResourcesToPortParams{
-		PortID:  building.PortID,
-		Wood:    wood,
-		Iron:    iron,
-		Rum:     rum,
-		Sugar:   sugar,
-		Tobacco: tobacco,
-		Cotton:  cotton,
-		Coffee:  coffee,
-		Grain:   grain,
-		Gold:    gold,
-		Silver:  silver,
-	})
+	// Demolition salvage is based on what the completed levels cost
+	cost, err := queueItemCost(ctx, q, item.ID)
 	if err != nil {
-		return fmt.Errorf("failed to add resources: %w", err)
+		return err
+	}
+	for resourceType, amount := range cost {
+		err = q.AddBuildingInvestment(ctx, db.AddBuildingInvestmentParams{
+			BuildingID:   item.BuildingID.Int32,
+			ResourceType: resourceType,
+			Amount:       amount,
+		})
+		if err != nil {
+			return err
+		}
 	}
 
-	return nil
-}
+	err = q.DeleteConstructionQueueItem(ctx, item.ID)
+	if err != nil {
+		return err
+	}
 
-func (s *Service) CompleteConstructions(ctx context.Context) error {
-	// Get buildings that should be completed
-	completedBuildings, err := s.queries.GetBuildingsUnderConstruction(ctx)
+	// Production starts from when the construction finished, not from when
+	// this sweep noticed.
+	err = s.refreshProductionRates(ctx, q, item.PortID, completedAt)
 	if err != nil {
-		return fmt.Errorf("failed to get completed buildings: %w", err)
+		return err
 	}
 
-	for _, building := range completedBuildings {
-		err := s.queries.CompleteBuildingConstruction(ctx, building.ID)
-		if err != nil {
-			fmt.Printf("Error completing construction for building %d: %v\n", building.ID, err)
-		}
+	// The freed slot picks up the next item from the same moment
+	err = s.startQueuedConstructions(ctx, q, item.PortID, completedAt)
+	if err != nil {
+		return err
 	}
 
-	return nil
-}
\ No newline at end of file
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,155 @@
+package island
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bradcypert/stserver/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrInsufficientResources = errors.New("insufficient resources")
+
+// Spend takes cost out of a port's resources as part of tx and records it in
+// the ledger. It locks the port's resources row until tx ends and fails with
+// ErrInsufficientResources, changing nothing, if the port can't cover the
+// whole cost.
+func (s *Service) Spend(ctx context.Context, tx pgx.Tx, portID int32, cost Resources, reason LedgerReason, reference string) error {
+	return s.spend(ctx, s.queries.WithTx(tx), portID, cost, reason, reference)
+}
+
+func (s *Service) spend(ctx context.Context, q *db.Queries, portID int32, cost Resources, reason LedgerReason, reference string) error {
+	// Settling locks the port's resources, so the amounts read below can't
+	// change before they are consumed
+	err := s.settlePort(ctx, q, portID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	available, err := portResources(ctx, q, portID)
+	if err != nil {
+		return err
+	}
+
+	var short []string
+	for resourceType, amount := range cost {
+		if amount < 0 {
+			return fmt.Errorf("cannot spend a negative amount of %s", resourceType)
+		}
+		if available[resourceType] < amount {
+			short = append(short, fmt.Sprintf("%s (need %d, have %d)", resourceType, amount, available[resourceType]))
+		}
+	}
+
+	if len(short) > 0 {
+		slices.Sort(short)
+		return fmt.Errorf("%w: %s", ErrInsufficientResources, strings.Join(short, ", "))
+	}
+
+	for resourceType, amount := range cost {
+		if amount == 0 {
+			continue
+		}
+
+		consumed, err := q.ConsumePortResource(ctx, db.ConsumePortResourceParams{
+			PortID:       portID,
+			ResourceType: resourceType,
+			Amount:       amount,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to consume %s: %w", resourceType, err)
+		}
+		if consumed == 0 {
+			return fmt.Errorf("%w: %s", ErrInsufficientResources, resourceType)
+		}
+	}
+
+	deltas := make(Resources, len(cost))
+	for resourceType, amount := range cost {
+		deltas[resourceType] = -amount
+	}
+	return recordLedger(ctx, q, portID, deltas, reason, reference)
+}
+
+// Deposit adds amounts to a port's resources as part of tx and records them
+// in the ledger. Like Spend, it settles and locks the port's resources row
+// until tx ends. Deposits can take a resource over its storage capacity; it
+// just stops growing until it drops back under.
+func (s *Service) Deposit(ctx context.Context, tx pgx.Tx, portID int32, amounts Resources, reason LedgerReason, reference string) error {
+	for resourceType, amount := range amounts {
+		if amount < 0 {
+			return fmt.Errorf("cannot deposit a negative amount of %s", resourceType)
+		}
+	}
+
+	q := s.queries.WithTx(tx)
+
+	// Settle first so the deposit doesn't count towards earlier production
+	err := s.settlePort(ctx, q, portID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return credit(ctx, q, portID, amounts, reason, reference)
+}
+
+// Loot takes up to share of every resource a port holds above its
+// protected storage, as part of tx, and records it in the ledger as a raid.
+// If that comes to more than capacity, each resource is scaled down by the
+// same proportion so the haul fits. It returns what was taken.
+func (s *Service) Loot(ctx context.Context, tx pgx.Tx, portID int32, share float64, capacity int32, reference string) (Resources, error) {
+	q := s.queries.WithTx(tx)
+
+	err := s.settlePort(ctx, q, portID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	available, err := portResources(ctx, q, portID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.islandStats(ctx, q, portID)
+	if err != nil {
+		return nil, err
+	}
+
+	loot := make(Resources, len(available))
+	var total int32
+	for resourceType, amount := range available {
+		exposed := amount - stats.ProtectedStorage
+		if exposed <= 0 {
+			continue
+		}
+		loot[resourceType] = int32(math.Floor(float64(exposed) * share))
+		total += loot[resourceType]
+	}
+
+	if total > capacity {
+		scale := float64(max(capacity, 0)) / float64(total)
+		for resourceType, amount := range loot {
+			loot[resourceType] = int32(math.Floor(float64(amount) * scale))
+		}
+	}
+
+	for resourceType, amount := range loot {
+		if amount <= 0 {
+			delete(loot, resourceType)
+		}
+	}
+	if len(loot) == 0 {
+		return loot, nil
+	}
+
+	err = s.spend(ctx, q, portID, loot, LedgerRaid, reference)
+	if err != nil {
+		return nil, err
+	}
+	return loot, nil
+}
@@ -0,0 +1,96 @@
+package island
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/bradcypert/stserver/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// Effects a building can have on its island, as named in building_effects.
+const (
+	EffectProductionMultiplier = "production_multiplier"
+	EffectBuildTimeReduction   = "build_time_reduction"
+	EffectDefenseRating        = "defense_rating"
+	EffectCrewCapacity         = "crew_capacity"
+	EffectTradeSlots           = "trade_slots"
+	EffectBuildSlots           = "build_slots"
+	EffectProtectedStorage     = "protected_storage"
+	EffectOrderSlots           = "order_slots"
+)
+
+// maxBuildTimeReduction caps how much building effects can shorten a
+// construction, however many are stacked.
+const maxBuildTimeReduction = 0.5
+
+// IslandStats are the modifiers an island gets from its operating
+// buildings. A building being upgraded keeps the effect of its previous
+// level until the upgrade finishes.
+type IslandStats struct {
+	ProductionMultiplier float64 `json:"production_multiplier"`
+	BuildTimeReduction   float64 `json:"build_time_reduction"`
+	DefenseRating        int32   `json:"defense_rating"`
+	CrewCapacity         int32   `json:"crew_capacity"`
+	TradeSlots           int32   `json:"trade_slots"`
+	BuildSlots           int32   `json:"build_slots"`
+	ProtectedStorage     int32   `json:"protected_storage"`
+	OrderSlots           int32   `json:"order_slots"`
+}
+
+// newIslandStats adds up the effect totals of an island's buildings.
+func newIslandStats(effects []db.GetPortBuildingEffectsRow) IslandStats {
+	stats := IslandStats{
+		ProductionMultiplier: 1,
+		BuildSlots:           baseBuildSlots,
+	}
+
+	for _, effect := range effects {
+		switch effect.Effect {
+		case EffectProductionMultiplier:
+			stats.ProductionMultiplier += effect.Value
+		case EffectBuildTimeReduction:
+			stats.BuildTimeReduction = min(effect.Value, maxBuildTimeReduction)
+		case EffectDefenseRating:
+			stats.DefenseRating += int32(math.Round(effect.Value))
+		case EffectCrewCapacity:
+			stats.CrewCapacity += int32(math.Round(effect.Value))
+		case EffectTradeSlots:
+			stats.TradeSlots += int32(math.Round(effect.Value))
+		case EffectBuildSlots:
+			stats.BuildSlots += int32(math.Round(effect.Value))
+		case EffectProtectedStorage:
+			stats.ProtectedStorage += int32(math.Round(effect.Value))
+		case EffectOrderSlots:
+			stats.OrderSlots += int32(math.Round(effect.Value))
+		}
+	}
+
+	return stats
+}
+
+// buildTime shortens a construction by the island's build time reduction.
+func (s IslandStats) buildTime(durationSeconds int32) int32 {
+	return int32(math.Round(float64(durationSeconds) * (1 - s.BuildTimeReduction)))
+}
+
+func (s *Service) islandStats(ctx context.Context, q *db.Queries, portID int32) (IslandStats, error) {
+	effects, err := q.GetPortBuildingEffects(ctx, portID)
+	if err != nil {
+		return IslandStats{}, fmt.Errorf("failed to get building effects: %w", err)
+	}
+	return newIslandStats(effects), nil
+}
+
+// GetIslandStats returns the modifiers the island's buildings currently give
+// it.
+func (s *Service) GetIslandStats(ctx context.Context, portID int32) (IslandStats, error) {
+	return s.islandStats(ctx, s.queries, portID)
+}
+
+// GetIslandStatsTx is GetIslandStats read as part of tx, so it sees the
+// buildings as tx does.
+func (s *Service) GetIslandStatsTx(ctx context.Context, tx pgx.Tx, portID int32) (IslandStats, error) {
+	return s.islandStats(ctx, s.queries.WithTx(tx), portID)
+}
@@ -0,0 +1,37 @@
+package island
+
+import (
+	"testing"
+
+	"github.com/bradcypert/stserver/internal/db"
+)
+
+func TestNewIslandStatsOrderSlotsIgnoreDocks(t *testing.T) {
+	// A level 3 dock and a level 1 trade center
+	effects := []db.GetPortBuildingEffectsRow{
+		{Effect: EffectCrewCapacity, Value: 30},
+		{Effect: EffectOrderSlots, Value: 2},
+		{Effect: EffectTradeSlots, Value: 5},
+	}
+
+	stats := newIslandStats(effects)
+
+	if stats.OrderSlots != 2 {
+		t.Fatalf("order slots = %d, want 2", stats.OrderSlots)
+	}
+	if stats.TradeSlots != 5 {
+		t.Fatalf("trade slots = %d, want 5", stats.TradeSlots)
+	}
+}
+
+func TestNewIslandStatsNoTradeCenter(t *testing.T) {
+	effects := []db.GetPortBuildingEffectsRow{
+		{Effect: EffectTradeSlots, Value: 1},
+	}
+
+	stats := newIslandStats(effects)
+
+	if stats.OrderSlots != 0 {
+		t.Fatalf("order slots = %d with only a dock, want 0", stats.OrderSlots)
+	}
+}
@@ -0,0 +1,422 @@
+package market
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/bradcypert/stserver/internal/db"
+	"github.com/bradcypert/stserver/internal/island"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// npcSpread is the gap between what the trading posts sell at and what
+	// they buy at, as a share of the price.
+	npcSpread = 0.1
+
+	// npcPriceHalfLife is how long it takes a price to recover half of the
+	// way back to its baseline.
+	npcPriceHalfLife = 6 * time.Hour
+
+	// Prices never drift further than this factor from their baseline.
+	npcMaxPriceFactor = 4.0
+
+	// NpcPriceHistoryWindow is how far back price history is kept.
+	NpcPriceHistoryWindow = 24 * time.Hour
+)
+
+var (
+	ErrNpcMarketClosed     = errors.New("the trading posts don't deal in this resource")
+	ErrTradingPostNotFound = errors.New("trading post not found")
+)
+
+// PricePoint is an NPC price as it was at one recalculation, with the net
+// volume players bought from the trading posts since the one before.
+type PricePoint struct {
+	Price      float64   `json:"price"`
+	Volume     int32     `json:"volume"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// NpcPrice is what the trading posts charge and pay for a resource right
+// now, in gold per unit and before each post's own terms, with its recent
+// history.
+type NpcPrice struct {
+	ResourceType  string       `json:"resource_type"`
+	BaselinePrice float64      `json:"baseline_price"`
+	Price         float64      `json:"price"`
+	BuyPrice      float64      `json:"buy_price"`
+	SellPrice     float64      `json:"sell_price"`
+	History       []PricePoint `json:"history"`
+}
+
+// NpcTrade is the result of a trade with a trading post. Gold is what the
+// player paid when buying and what they got when selling, tariff included.
+type NpcTrade struct {
+	PostID       int32  `json:"post_id"`
+	Side         string `json:"side"`
+	ResourceType string `json:"resource_type"`
+	Quantity     int32  `json:"quantity"`
+	Gold         int32  `json:"gold"`
+	Tariff       int32  `json:"tariff"`
+}
+
+// NpcTradeRequest buys or sells Quantity of a resource with a trading post
+// from a player's port.
+type NpcTradeRequest struct {
+	PlayerID     int32  `json:"player_id"`
+	PortID       int32  `json:"port_id"`
+	PostID       int32  `json:"post_id"`
+	Side         string `json:"side"`
+	ResourceType string `json:"resource_type"`
+	Quantity     int32  `json:"quantity"`
+}
+
+// TradeTerms are how a trading post deals with a player, given how the
+// post's faction stands with the player's. Prices are scaled by the
+// faction's modifiers, then improved (or worsened) by RateBonus, and
+// TariffRate of the trade's value is charged on top.
+type TradeTerms struct {
+	Stance       string  `json:"stance"`
+	BuyModifier  float64 `json:"buy_modifier"`
+	SellModifier float64 `json:"sell_modifier"`
+	RateBonus    float64 `json:"rate_bonus"`
+	TariffRate   float64 `json:"tariff_rate"`
+}
+
+func newTradeTerms(faction db.Faction, stance db.FactionStance) TradeTerms {
+	return TradeTerms{
+		Stance:       stance.Stance,
+		BuyModifier:  faction.BuyModifier,
+		SellModifier: faction.SellModifier,
+		RateBonus:    stance.RateBonus,
+		TariffRate:   faction.TariffRate * stance.TariffMultiplier,
+	}
+}
+
+// buyPrice is what the player pays per unit at price.
+func (t TradeTerms) buyPrice(price float64) float64 {
+	return buyPrice(price) * t.BuyModifier * (1 - t.RateBonus)
+}
+
+// sellPrice is what the player is paid per unit at price.
+func (t TradeTerms) sellPrice(price float64) float64 {
+	return sellPrice(price) * t.SellModifier * (1 + t.RateBonus)
+}
+
+// TradingPost is an NPC trading post with the faction that runs it. Terms
+// are only filled in when the post is looked at on behalf of a player.
+type TradingPost struct {
+	db.NpcTradingPost
+	Faction db.Faction  `json:"faction"`
+	Terms   *TradeTerms `json:"terms,omitempty"`
+}
+
+// buyPrice is what players pay the trading posts per unit, before terms.
+func buyPrice(price float64) float64 {
+	return price * (1 + npcSpread/2)
+}
+
+// sellPrice is what the trading posts pay players per unit, before terms.
+func sellPrice(price float64) float64 {
+	return price * (1 - npcSpread/2)
+}
+
+// driftPrice works out a new price from the volume players traded since
+// the last recalculation, elapsed time ago. Buying pushes the price up and
+// selling pushes it down, by a factor of e for every depth units, and then
+// the price recovers towards its baseline over the elapsed time.
+func driftPrice(price db.NpcMarketPrice, elapsed time.Duration) float64 {
+	drifted := price.Price * math.Exp(float64(price.PendingVolume)/float64(price.Depth))
+
+	recovery := math.Pow(0.5, elapsed.Hours()/npcPriceHalfLife.Hours())
+	drifted = price.BaselinePrice + (drifted-price.BaselinePrice)*recovery
+
+	return min(max(drifted, price.BaselinePrice/npcMaxPriceFactor), price.BaselinePrice*npcMaxPriceFactor)
+}
+
+// RecalculateNpcPrices moves every NPC price by the player volume since the
+// last recalculation and towards its baseline, and records the result in
+// the price history. History older than NpcPriceHistoryWindow is dropped.
+func (s *Service) RecalculateNpcPrices(ctx context.Context, at time.Time) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.queries.WithTx(tx)
+	recordedAt := pgtype.Timestamptz{Time: at, Valid: true}
+
+	prices, err := q.LockNpcPrices(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to lock prices: %w", err)
+	}
+
+	for _, price := range prices {
+		newPrice := driftPrice(price, at.Sub(price.UpdatedAt.Time))
+
+		err = q.UpdateNpcPrice(ctx, db.UpdateNpcPriceParams{
+			ResourceType: price.ResourceType,
+			Price:        newPrice,
+			UpdatedAt:    recordedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update %s price: %w", price.ResourceType, err)
+		}
+
+		err = q.InsertNpcPriceHistory(ctx, db.InsertNpcPriceHistoryParams{
+			ResourceType: price.ResourceType,
+			Price:        newPrice,
+			Volume:       price.PendingVolume,
+			RecordedAt:   recordedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record %s price: %w", price.ResourceType, err)
+		}
+	}
+
+	err = q.DeleteNpcPriceHistoryBefore(ctx, pgtype.Timestamptz{Time: at.Add(-NpcPriceHistoryWindow), Valid: true})
+	if err != nil {
+		return fmt.Errorf("failed to prune price history: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetNpcPrices returns the current NPC prices with their history since
+// the given time.
+func (s *Service) GetNpcPrices(ctx context.Context, since time.Time) ([]NpcPrice, error) {
+	prices, err := s.queries.GetNpcPrices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prices: %w", err)
+	}
+
+	history, err := s.queries.GetNpcPriceHistorySince(ctx, pgtype.Timestamptz{Time: since, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price history: %w", err)
+	}
+
+	points := make(map[string][]PricePoint, len(prices))
+	for _, h := range history {
+		points[h.ResourceType] = append(points[h.ResourceType], PricePoint{
+			Price:      h.Price,
+			Volume:     h.Volume,
+			RecordedAt: h.RecordedAt.Time,
+		})
+	}
+
+	result := make([]NpcPrice, 0, len(prices))
+	for _, price := range prices {
+		p := NpcPrice{
+			ResourceType:  price.ResourceType,
+			BaselinePrice: price.BaselinePrice,
+			Price:         price.Price,
+			BuyPrice:      buyPrice(price.Price),
+			SellPrice:     sellPrice(price.Price),
+			History:       points[price.ResourceType],
+		}
+		if p.History == nil {
+			p.History = []PricePoint{}
+		}
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+// GetTradingPosts returns every NPC trading post. When playerID is given
+// each post comes with the terms it offers that player.
+func (s *Service) GetTradingPosts(ctx context.Context, playerID *int32) ([]TradingPost, error) {
+	posts, err := s.queries.GetNpcTradingPosts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trading posts: %w", err)
+	}
+
+	factions, err := s.queries.GetAllFactions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get factions: %w", err)
+	}
+
+	var player db.Player
+	if playerID != nil {
+		player, err = s.queries.GetPlayerByID(ctx, *playerID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get player: %w", err)
+		}
+	}
+
+	result := make([]TradingPost, 0, len(posts))
+	for _, post := range posts {
+		i := slices.IndexFunc(factions, func(f db.Faction) bool { return f.ID == post.FactionID })
+		if i < 0 {
+			return nil, fmt.Errorf("faction %d of trading post %d not found", post.FactionID, post.ID)
+		}
+
+		tradingPost := TradingPost{NpcTradingPost: post, Faction: factions[i]}
+		if playerID != nil {
+			stance, err := s.queries.GetTradeStance(ctx, db.GetTradeStanceParams{
+				PostFaction:   post.FactionID,
+				PlayerFaction: player.Faction,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get faction stance: %w", err)
+			}
+
+			terms := newTradeTerms(factions[i], stance)
+			tradingPost.Terms = &terms
+		}
+		result = append(result, tradingPost)
+	}
+	return result, nil
+}
+
+// tradeTerms looks up how a trading post deals with a player.
+func (s *Service) tradeTerms(ctx context.Context, q *db.Queries, post db.NpcTradingPost, playerID int32) (TradeTerms, error) {
+	player, err := q.GetPlayerByID(ctx, playerID)
+	if err != nil {
+		return TradeTerms{}, fmt.Errorf("failed to get player: %w", err)
+	}
+
+	faction, err := q.GetFactionByID(ctx, post.FactionID)
+	if err != nil {
+		return TradeTerms{}, fmt.Errorf("failed to get faction: %w", err)
+	}
+
+	stance, err := q.GetTradeStance(ctx, db.GetTradeStanceParams{
+		PostFaction:   post.FactionID,
+		PlayerFaction: player.Faction,
+	})
+	if err != nil {
+		return TradeTerms{}, fmt.Errorf("failed to get faction stance: %w", err)
+	}
+
+	return newTradeTerms(faction, stance), nil
+}
+
+// TradeWithNpcs buys from or sells to a trading post at the current NPC
+// price, on the terms the post's faction gives the player, paying or being
+// paid in gold at the port. The tariff is paid on top when buying and taken
+// out of the proceeds when selling, and goes into the post faction's
+// treasury. Like the player market it needs a trade center. The volume
+// counts towards the next price recalculation.
+func (s *Service) TradeWithNpcs(ctx context.Context, req NpcTradeRequest) (*NpcTrade, error) {
+	if req.Side != SideBuy && req.Side != SideSell {
+		return nil, fmt.Errorf("%w: side must be %q or %q", ErrInvalidOrder, SideBuy, SideSell)
+	}
+	if req.Quantity <= 0 {
+		return nil, fmt.Errorf("%w: quantity must be positive", ErrInvalidOrder)
+	}
+
+	post, err := s.queries.GetNpcTradingPost(ctx, req.PostID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTradingPostNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trading post: %w", err)
+	}
+
+	tradeCenterLevel, err := s.queries.GetPortTradeCenterLevel(ctx, req.PortID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trade center level: %w", err)
+	}
+	if tradeCenterLevel < 1 {
+		return nil, ErrTradeCenterRequired
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.queries.WithTx(tx)
+
+	// Holds the price still until the trade is done
+	price, err := q.LockNpcPrice(ctx, req.ResourceType)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrNpcMarketClosed, req.ResourceType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price: %w", err)
+	}
+
+	terms, err := s.tradeTerms(ctx, q, post, req.PlayerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rounded in the trading post's favour
+	var value float64
+	if req.Side == SideBuy {
+		value = math.Ceil(float64(req.Quantity) * terms.buyPrice(price.Price))
+	} else {
+		value = math.Floor(float64(req.Quantity) * terms.sellPrice(price.Price))
+	}
+	tariff := math.Ceil(value * terms.TariffRate)
+
+	gold := value + tariff
+	if req.Side == SideSell {
+		gold = max(value-tariff, 0)
+		tariff = value - gold
+	}
+	if gold > math.MaxInt32 {
+		return nil, fmt.Errorf("%w: trade is worth too much gold", ErrInvalidOrder)
+	}
+
+	trade := &NpcTrade{
+		PostID:       post.ID,
+		Side:         req.Side,
+		ResourceType: req.ResourceType,
+		Quantity:     req.Quantity,
+		Gold:         int32(gold),
+		Tariff:       int32(tariff),
+	}
+
+	paid, received := island.Resources{Gold: trade.Gold}, island.Resources{req.ResourceType: req.Quantity}
+	volume := req.Quantity
+	if req.Side == SideSell {
+		paid, received = received, paid
+		volume = -volume
+	}
+
+	reference := island.LedgerReference(post.ID)
+	err = s.islands.Spend(ctx, tx, req.PortID, paid, island.LedgerTrade, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.islands.Deposit(ctx, tx, req.PortID, received, island.LedgerTrade, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	err = q.AddNpcVolume(ctx, db.AddNpcVolumeParams{
+		ResourceType:  req.ResourceType,
+		PendingVolume: volume,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record trade volume: %w", err)
+	}
+
+	if trade.Tariff > 0 {
+		err = q.AddFactionTreasury(ctx, db.AddFactionTreasuryParams{
+			ID:       post.FactionID,
+			Treasury: int64(trade.Tariff),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to pay tariff: %w", err)
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return trade, nil
+}
@@ -0,0 +1,483 @@
+package market
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/bradcypert/stserver/internal/db"
+	"github.com/bradcypert/stserver/internal/events"
+	"github.com/bradcypert/stserver/internal/island"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Gold is what every order is priced in, so it can't be traded itself.
+const Gold = "gold"
+
+const (
+	SideBuy  = "buy"
+	SideSell = "sell"
+)
+
+const (
+	OrderStatusOpen      = "open"
+	OrderStatusFilled    = "filled"
+	OrderStatusCancelled = "cancelled"
+	OrderStatusExpired   = "expired"
+)
+
+const (
+	DefaultOrderLifetime = 24 * time.Hour
+	MaxOrderLifetime     = 7 * 24 * time.Hour
+)
+
+// recentTradeLimit is how many trades an order book shows.
+const recentTradeLimit = 20
+
+var (
+	ErrOrderNotFound       = errors.New("order not found")
+	ErrOrderNotOpen        = errors.New("order is no longer open")
+	ErrInvalidOrder        = errors.New("invalid order")
+	ErrUnknownResource     = errors.New("unknown resource type")
+	ErrTradeCenterRequired = errors.New("a trade center is required to trade")
+	ErrTooManyOpenOrders   = errors.New("no free order slots")
+)
+
+type OrderExpirePayload struct {
+	OrderID int32 `json:"order_id"`
+}
+
+// PlaceOrderRequest is a limit order from a player's port. A buy order pays
+// at most PricePerUnit gold per unit and a sell order takes at least that.
+// ExpiresIn defaults to DefaultOrderLifetime when zero.
+type PlaceOrderRequest struct {
+	PlayerID     int32         `json:"player_id"`
+	PortID       int32         `json:"port_id"`
+	Side         string        `json:"side"`
+	ResourceType string        `json:"resource_type"`
+	PricePerUnit int32         `json:"price_per_unit"`
+	Quantity     int32         `json:"quantity"`
+	ExpiresIn    time.Duration `json:"expires_in"`
+}
+
+// PlacedOrder is a new order as it stands after matching, with the trades
+// it made on the way in.
+type PlacedOrder struct {
+	Order  db.MarketOrder   `json:"order"`
+	Trades []db.MarketTrade `json:"trades"`
+}
+
+// PriceLevel is the open quantity at one price on one side of the book.
+type PriceLevel struct {
+	PricePerUnit int32 `json:"price_per_unit"`
+	Quantity     int32 `json:"quantity"`
+	Orders       int32 `json:"orders"`
+}
+
+// OrderBook is the open orders for a resource, best price first on each
+// side, and its most recent trades.
+type OrderBook struct {
+	ResourceType string           `json:"resource_type"`
+	Bids         []PriceLevel     `json:"bids"`
+	Asks         []PriceLevel     `json:"asks"`
+	RecentTrades []db.MarketTrade `json:"recent_trades"`
+}
+
+// escrow is what an order still holds back from its port: the resource for
+// a sell order and the gold to pay for it for a buy order.
+func escrow(order db.MarketOrder) island.Resources {
+	if order.Side == SideSell {
+		return island.Resources{order.ResourceType: order.Remaining}
+	}
+	return island.Resources{Gold: order.Remaining * order.PricePerUnit}
+}
+
+func (s *Service) validateOrder(ctx context.Context, req *PlaceOrderRequest) error {
+	if req.Side != SideBuy && req.Side != SideSell {
+		return fmt.Errorf("%w: side must be %q or %q", ErrInvalidOrder, SideBuy, SideSell)
+	}
+	if req.ResourceType == Gold {
+		return fmt.Errorf("%w: orders are priced in gold, it can't be traded", ErrInvalidOrder)
+	}
+	if req.PricePerUnit <= 0 || req.Quantity <= 0 {
+		return fmt.Errorf("%w: price and quantity must be positive", ErrInvalidOrder)
+	}
+	if int64(req.PricePerUnit)*int64(req.Quantity) > math.MaxInt32 {
+		return fmt.Errorf("%w: order is worth too much gold", ErrInvalidOrder)
+	}
+
+	if req.ExpiresIn == 0 {
+		req.ExpiresIn = DefaultOrderLifetime
+	}
+	if req.ExpiresIn < time.Minute || req.ExpiresIn > MaxOrderLifetime {
+		return fmt.Errorf("%w: orders last between a minute and %s", ErrInvalidOrder, MaxOrderLifetime)
+	}
+
+	resourceTypes, err := s.queries.GetAllResourceTypes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get resource types: %w", err)
+	}
+	if !slices.ContainsFunc(resourceTypes, func(t db.ResourceType) bool { return t.Name == req.ResourceType }) {
+		return fmt.Errorf("%w: %s", ErrUnknownResource, req.ResourceType)
+	}
+	return nil
+}
+
+// PlaceOrder posts a limit order. What it offers goes into escrow, then it
+// is matched against the other side of the book: best price first, oldest
+// first at the same price, always trading at the resting order's price. A
+// buy order that fills below its limit gets the difference back straight
+// away. Whatever isn't filled stays on the book until it is filled,
+// cancelled or expires.
+//
+// Only ports with a trade center can trade, and they can hold no more open
+// orders than they have trade slots.
+func (s *Service) PlaceOrder(ctx context.Context, req PlaceOrderRequest) (*PlacedOrder, error) {
+	err := s.validateOrder(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	tradeCenterLevel, err := s.queries.GetPortTradeCenterLevel(ctx, req.PortID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trade center level: %w", err)
+	}
+	if tradeCenterLevel < 1 {
+		return nil, ErrTradeCenterRequired
+	}
+
+	stats, err := s.islands.GetIslandStats(ctx, req.PortID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.queries.WithTx(tx)
+	now := time.Now()
+
+	// Orders are matched one at a time across the whole market. Two crossing
+	// orders can't both miss each other and rest on the book, and a match
+	// that locks several ports can't deadlock with another one
+	err = q.LockMarket(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock market: %w", err)
+	}
+
+	order, err := q.CreateMarketOrder(ctx, db.CreateMarketOrderParams{
+		PlayerID:     req.PlayerID,
+		PortID:       req.PortID,
+		Side:         req.Side,
+		ResourceType: req.ResourceType,
+		PricePerUnit: req.PricePerUnit,
+		Quantity:     req.Quantity,
+		ExpiresAt:    pgtype.Timestamptz{Time: now.Add(req.ExpiresIn), Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create order: %w", err)
+	}
+
+	// Spending locks the port, so the open order count below can't race
+	// another order from the same port
+	err = s.islands.Spend(ctx, tx, req.PortID, escrow(order), island.LedgerTrade, island.LedgerReference(order.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	openOrders, err := q.CountOpenPortOrders(ctx, req.PortID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count open orders: %w", err)
+	}
+	if openOrders > stats.OrderSlots {
+		return nil, fmt.Errorf("%w: %d of %d in use", ErrTooManyOpenOrders, openOrders-1, stats.OrderSlots)
+	}
+
+	trades, err := s.match(ctx, tx, q, order, now)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err = q.GetMarketOrder(ctx, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	if order.Status == OrderStatusOpen {
+		event, err := events.NewEvent(events.GameEventMarketOrderExpire, OrderExpirePayload{OrderID: order.ID})
+		if err != nil {
+			return nil, err
+		}
+
+		// Scheduled before committing so escrow is never stuck on the book.
+		// The handler ignores orders that are no longer open.
+		err = s.events.Schedule(ctx, event, order.ExpiresAt.Time)
+		if err != nil {
+			return nil, fmt.Errorf("failed to schedule order expiry: %w", err)
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PlacedOrder{Order: order, Trades: trades}, nil
+}
+
+// match fills a new order against the resting orders on the other side of
+// the book that cross it. Players never trade with themselves.
+func (s *Service) match(ctx context.Context, tx pgx.Tx, q *db.Queries, order db.MarketOrder, now time.Time) ([]db.MarketTrade, error) {
+	var resting []db.MarketOrder
+	var err error
+	if order.Side == SideBuy {
+		resting, err = q.GetMatchingSellOrders(ctx, db.GetMatchingSellOrdersParams{
+			ResourceType: order.ResourceType,
+			Now:          pgtype.Timestamptz{Time: now, Valid: true},
+			MaxPrice:     order.PricePerUnit,
+			PlayerID:     order.PlayerID,
+		})
+	} else {
+		resting, err = q.GetMatchingBuyOrders(ctx, db.GetMatchingBuyOrdersParams{
+			ResourceType: order.ResourceType,
+			Now:          pgtype.Timestamptz{Time: now, Valid: true},
+			MinPrice:     order.PricePerUnit,
+			PlayerID:     order.PlayerID,
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get matching orders: %w", err)
+	}
+
+	trades := []db.MarketTrade{}
+	for _, other := range resting {
+		if order.Remaining == 0 {
+			break
+		}
+
+		buy, sell := order, other
+		if order.Side == SideSell {
+			buy, sell = other, order
+		}
+
+		quantity := min(order.Remaining, other.Remaining)
+		trade, err := s.fill(ctx, tx, q, buy, sell, quantity, other.PricePerUnit)
+		if err != nil {
+			return nil, err
+		}
+
+		order.Remaining -= quantity
+		trades = append(trades, trade)
+	}
+	return trades, nil
+}
+
+// fill trades quantity between a buy and a sell order at price. The buyer
+// gets the resource and any gold it escrowed above price, and the seller
+// gets paid, both out of escrow.
+func (s *Service) fill(ctx context.Context, tx pgx.Tx, q *db.Queries, buy, sell db.MarketOrder, quantity, price int32) (db.MarketTrade, error) {
+	trade, err := q.CreateMarketTrade(ctx, db.CreateMarketTradeParams{
+		ResourceType: buy.ResourceType,
+		BuyOrderID:   buy.ID,
+		SellOrderID:  sell.ID,
+		Quantity:     quantity,
+		PricePerUnit: price,
+	})
+	if err != nil {
+		return trade, fmt.Errorf("failed to record trade: %w", err)
+	}
+
+	for _, order := range []db.MarketOrder{buy, sell} {
+		err = q.FillMarketOrder(ctx, db.FillMarketOrderParams{
+			Quantity: quantity,
+			ID:       order.ID,
+		})
+		if err != nil {
+			return trade, fmt.Errorf("failed to fill order: %w", err)
+		}
+	}
+
+	bought := island.Resources{
+		buy.ResourceType: quantity,
+		Gold:             (buy.PricePerUnit - price) * quantity,
+	}
+	err = s.islands.Deposit(ctx, tx, buy.PortID, bought, island.LedgerTrade, island.LedgerReference(buy.ID))
+	if err != nil {
+		return trade, err
+	}
+
+	err = s.islands.Deposit(ctx, tx, sell.PortID, island.Resources{Gold: price * quantity}, island.LedgerTrade, island.LedgerReference(sell.ID))
+	if err != nil {
+		return trade, err
+	}
+
+	return trade, nil
+}
+
+// closeOrder takes an open order off the book with the given status and
+// returns what is left of its escrow to its port.
+func (s *Service) closeOrder(ctx context.Context, tx pgx.Tx, q *db.Queries, order db.MarketOrder, status string) error {
+	err := q.CloseMarketOrder(ctx, db.CloseMarketOrderParams{
+		ID:     order.ID,
+		Status: status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to close order: %w", err)
+	}
+
+	return s.islands.Deposit(ctx, tx, order.PortID, escrow(order), island.LedgerRefund, island.LedgerReference(order.ID))
+}
+
+// lockOpenOrder locks an order, and the market, for the rest of the
+// transaction.
+func lockOpenOrder(ctx context.Context, q *db.Queries, orderID int32) (db.MarketOrder, error) {
+	order, err := q.GetMarketOrder(ctx, orderID)
+	if err != nil {
+		return order, err
+	}
+
+	// Taken first, like PlaceOrder does, so this waits for any matching in
+	// progress instead of deadlocking with it
+	err = q.LockMarket(ctx)
+	if err != nil {
+		return order, fmt.Errorf("failed to lock market: %w", err)
+	}
+
+	return q.LockMarketOrder(ctx, orderID)
+}
+
+// CancelOrder takes one of a player's open orders off the book and returns
+// the unfilled part of its escrow.
+func (s *Service) CancelOrder(ctx context.Context, playerID, orderID int32) (*db.MarketOrder, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.queries.WithTx(tx)
+
+	order, err := lockOpenOrder(ctx, q, orderID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && order.PlayerID != playerID) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	if order.Status != OrderStatusOpen {
+		return nil, ErrOrderNotOpen
+	}
+
+	err = s.closeOrder(ctx, tx, q, order, OrderStatusCancelled)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err = q.GetMarketOrder(ctx, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// HandleOrderExpireEvent takes an order off the book once its time is up
+// and returns its escrow. Orders that were filled or cancelled in the
+// meantime are left alone.
+func (s *Service) HandleOrderExpireEvent(ctx context.Context, payload OrderExpirePayload) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.queries.WithTx(tx)
+
+	order, err := lockOpenOrder(ctx, q, payload.OrderID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get order: %w", err)
+	}
+	if order.Status != OrderStatusOpen {
+		return nil
+	}
+
+	err = s.closeOrder(ctx, tx, q, order, OrderStatusExpired)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetOrderBook returns the open orders for a resource grouped by price.
+func (s *Service) GetOrderBook(ctx context.Context, resourceType string) (*OrderBook, error) {
+	levels, err := s.queries.GetOrderBook(ctx, db.GetOrderBookParams{
+		ResourceType: resourceType,
+		Now:          pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order book: %w", err)
+	}
+
+	trades, err := s.queries.GetRecentMarketTrades(ctx, db.GetRecentMarketTradesParams{
+		ResourceType: resourceType,
+		Limit:        recentTradeLimit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent trades: %w", err)
+	}
+
+	book := &OrderBook{
+		ResourceType: resourceType,
+		Bids:         []PriceLevel{},
+		Asks:         []PriceLevel{},
+		RecentTrades: trades,
+	}
+	if book.RecentTrades == nil {
+		book.RecentTrades = []db.MarketTrade{}
+	}
+
+	for _, level := range levels {
+		priceLevel := PriceLevel{
+			PricePerUnit: level.PricePerUnit,
+			Quantity:     level.Quantity,
+			Orders:       level.Orders,
+		}
+		if level.Side == SideBuy {
+			book.Bids = append(book.Bids, priceLevel)
+		} else {
+			book.Asks = append(book.Asks, priceLevel)
+		}
+	}
+
+	// Levels come back cheapest first, but the best bid is the highest
+	slices.Reverse(book.Bids)
+	return book, nil
+}
+
+// GetPlayerOrders returns a player's most recent orders, open or not.
+func (s *Service) GetPlayerOrders(ctx context.Context, playerID int32) ([]db.MarketOrder, error) {
+	orders, err := s.queries.GetPlayerMarketOrders(ctx, playerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders: %w", err)
+	}
+	if orders == nil {
+		orders = []db.MarketOrder{}
+	}
+	return orders, nil
+}
@@ -0,0 +1,34 @@
+package market
+
+import (
+	"github.com/bradcypert/stserver/internal/db"
+	"github.com/bradcypert/stserver/internal/events"
+	"github.com/bradcypert/stserver/internal/island"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Service runs the player market. Everything an order offers is held in
+// escrow from the moment it is posted, so a match can always be settled.
+// Resources move in and out of ports through the island service so they
+// land in each port's ledger.
+type Service struct {
+	queries *db.Queries
+	pool    *pgxpool.Pool
+	events  *events.Queue
+	islands *island.Service
+}
+
+func NewService(pool *pgxpool.Pool, queue *events.Queue, islandService *island.Service) *Service {
+	return &Service{
+		queries: db.New(pool),
+		pool:    pool,
+		events:  queue,
+		islands: islandService,
+	}
+}
+
+// RegisterEventHandlers registers the game event handlers that need the
+// market service.
+func RegisterEventHandlers(registry *events.Registry, s *Service) {
+	events.Register(registry, events.GameEventMarketOrderExpire, s.HandleOrderExpireEvent)
+}